@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+// structuredAuthTest is a keploy test whose request records its auth as an
+// object next to the headers carrying it.
+func structuredAuthTest(auth ...string) *fstest.MapFile {
+	lines := []string{
+		"version: api.keploy.io/v1beta1",
+		"kind: Http",
+		"name: test-1",
+		"spec:",
+		"  req:",
+		"    method: GET",
+		"    url: http://api/users",
+		"    header:",
+		"      Authorization: Basic dXNlcjpwYXNz",
+		"      X-Api-Key: recorded",
+		"    auth:",
+	}
+	for _, line := range auth {
+		lines = append(lines, "      "+line)
+	}
+	return keployTest("curl http://api/users -H 'Authorization: Basic dXNlcjpwYXNz' -H 'X-Api-Key: recorded' -H 'Accept: */*'", lines...)
+}
+
+func TestRecordedAuthReplacesInferredAuth(t *testing.T) {
+	tests := []struct {
+		auth    []string
+		want    string
+		headers string
+	}{
+		{[]string{"type: bearer", "token: abc"}, "bearer [token=abc]", "X-Api-Key Accept"},
+		{[]string{"type: basic", "username: alice", "password: pw"}, "basic [username=alice password=pw]", "X-Api-Key Accept"},
+		{[]string{"type: apikey", "key: X-Api-Key", "value: k1"}, "apikey [key=X-Api-Key value=k1 in=header]", "Accept"},
+		{[]string{"type: apikey", "key: api_key", "value: k1", "in: query"}, "apikey [key=api_key value=k1 in=query]", "X-Api-Key Accept"},
+		{[]string{"type: noauth"}, "noauth []", "X-Api-Key Accept"},
+		{[]string{"type: digest", "username: alice"}, "basic [username=user password=pass]", "X-Api-Key Accept"},
+	}
+	for _, tt := range tests {
+		fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": structuredAuthTest(tt.auth...)}
+		request := testRequest(firstRequest(t, generateTestCollection(t, fsys, testOptions())))
+		auth, _ := request["auth"].(map[string]interface{})
+		authType, _ := auth["type"].(string)
+		fields := []string{}
+		if values, ok := auth[authType].([]interface{}); ok {
+			for _, v := range values {
+				field := v.(map[string]interface{})
+				fields = append(fields, fmt.Sprintf("%v=%v", field["key"], field["value"]))
+			}
+		}
+		if got := fmt.Sprintf("%s %v", authType, fields); got != tt.want {
+			t.Errorf("%v: auth = %s, want %s", tt.auth, got, tt.want)
+		}
+		headers := []string{}
+		for _, v := range request["header"].([]interface{}) {
+			headers = append(headers, fmt.Sprint(v.(map[string]interface{})["key"]))
+		}
+		if got := fmt.Sprint(headers); got != "["+tt.headers+"]" {
+			t.Errorf("%v: headers = %s, want [%s]", tt.auth, got, tt.headers)
+		}
+	}
+}
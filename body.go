@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// minifyBody compacts a raw JSON body in place. Bodies that are not valid
+// JSON are left untouched.
+func minifyBody(item map[string]interface{}) {
+	body := requestBody(item)
+	raw, _ := body["raw"].(string)
+	if raw == "" {
+		return
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(raw)); err != nil {
+		return
+	}
+	body["raw"] = compact.String()
+}
+
+// bodyLanguages are the languages -pretty-bodies can force.
+var bodyLanguages = map[string]bool{"json": true, "text": true, "xml": true, "html": true, "javascript": true}
+
+// setBodyLanguage overrides the language Postman uses to highlight a raw
+// body, for APIs whose content type does not describe what they send.
+func setBodyLanguage(item map[string]interface{}, language string) {
+	body := requestBody(item)
+	if body["mode"] != "raw" || body["raw"] == "" {
+		return
+	}
+	body["options"] = map[string]interface{}{
+		"raw": map[string]interface{}{"language": language},
+	}
+}
+
+// bodyLanguage picks the Postman language (json, xml, html, javascript or
+// text) for a body from its content type, falling back to sniffing it.
+func bodyLanguage(contentType, body string) string {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"), isNDJSON(body):
+		// A stream of JSON documents is not itself valid JSON, so keep it as
+		// text rather than letting Postman flag or reformat it
+		return "text"
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "html"):
+		return "html"
+	case strings.Contains(contentType, "xml"):
+		return "xml"
+	case strings.Contains(contentType, "javascript"):
+		return "javascript"
+	}
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed != "" && json.Valid([]byte(trimmed)):
+		return "json"
+	case strings.HasPrefix(strings.ToLower(trimmed), "<!doctype html"), strings.HasPrefix(strings.ToLower(trimmed), "<html"):
+		return "html"
+	case looksLikeXML(trimmed):
+		return "xml"
+	}
+	return "text"
+}
+
+// isNDJSON reports whether body holds several JSON documents, one per line.
+func isNDJSON(body string) bool {
+	documents := 0
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return false
+		}
+		documents++
+	}
+	return documents > 1
+}
+
+// dechunkBody strips chunked transfer-encoding framing that was captured along
+// with a body. It returns the body unchanged when it is not validly framed.
+func dechunkBody(raw string) string {
+	decoded, err := io.ReadAll(httputil.NewChunkedReader(strings.NewReader(raw)))
+	if err != nil {
+		return raw
+	}
+	return string(decoded)
+}
+
+// looksFormEncoded reports whether a body is a key=value&... form rather
+// than JSON or free text.
+func looksFormEncoded(raw string) bool {
+	if !strings.Contains(raw, "=") || strings.ContainsAny(raw, "{[\n\" ") {
+		return false
+	}
+	_, err := url.ParseQuery(raw)
+	return err == nil
+}
+
+// redactedValue replaces the values of redacted body keys.
+const redactedValue = `"***"`
+
+// redactBody masks the value of every JSON key listed in keys, at any depth,
+// in a raw JSON body. The rest of the body is left byte for byte as recorded,
+// so key order and formatting survive.
+func redactBody(item map[string]interface{}, keys map[string]bool) {
+	body := requestBody(item)
+	raw, _ := body["raw"].(string)
+	if raw == "" || !json.Valid([]byte(raw)) {
+		return
+	}
+
+	type span struct{ start, end int64 }
+	spans := []span{}
+	// containers holds the open objects and arrays; for an object it tracks
+	// whether the next token is a key
+	type container struct{ object, expectKey bool }
+	containers := []container{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if n := len(containers); n > 0 && containers[n-1].expectKey {
+			if key, ok := tok.(string); ok {
+				containers[n-1].expectKey = false
+				if keys[strings.ToLower(key)] {
+					start := dec.InputOffset()
+					if err := skipJSONValue(dec); err != nil {
+						return
+					}
+					spans = append(spans, span{start, dec.InputOffset()})
+					containers[n-1].expectKey = true
+				}
+				continue
+			}
+		}
+		switch tok {
+		case json.Delim('{'):
+			containers = append(containers, container{object: true, expectKey: true})
+			continue
+		case json.Delim('['):
+			containers = append(containers, container{})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			containers = containers[:len(containers)-1]
+		}
+		// A value just ended, so inside an object a key comes next
+		if n := len(containers); n > 0 && containers[n-1].object {
+			containers[n-1].expectKey = true
+		}
+	}
+
+	redacted := raw
+	for i := len(spans) - 1; i >= 0; i-- {
+		// Each span runs from just after the key to the end of its value
+		segment := redacted[spans[i].start:spans[i].end]
+		colon := strings.IndexByte(segment, ':')
+		prefix := segment[:colon+1]
+		rest := segment[colon+1:]
+		prefix += rest[:len(rest)-len(strings.TrimLeft(rest, " \t\r\n"))]
+		redacted = redacted[:spans[i].start] + prefix + redactedValue + redacted[spans[i].end:]
+	}
+	body["raw"] = redacted
+}
+
+// skipJSONValue consumes the next value, including everything nested in it.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// inferSchema derives a minimal JSON schema from an example value decoded
+// with UseNumber: types, object properties (all required) and array items,
+// taken from the first element.
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties := map[string]interface{}{}
+		required := make([]string, 0, len(v))
+		for key, property := range v {
+			properties[key] = inferSchema(property)
+			required = append(required, key)
+		}
+		sort.Strings(required)
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case []interface{}:
+		schema := map[string]interface{}{"type": "array"}
+		if len(v) > 0 {
+			schema["items"] = inferSchema(v[0])
+		}
+		return schema
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	}
+	return map[string]interface{}{"type": "null"}
+}
+
+// describeBodySchema documents the shape of a raw JSON body by adding its
+// inferred schema to the request description.
+func describeBodySchema(item map[string]interface{}) {
+	raw, _ := requestBody(item)["raw"].(string)
+	if raw == "" {
+		return
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var example interface{}
+	if err := dec.Decode(&example); err != nil {
+		return
+	}
+	schema, err := json.MarshalIndent(inferSchema(example), "", "  ")
+	if err != nil {
+		return
+	}
+	appendDescription(item, "Request body schema:\n\n```json\n"+string(schema)+"\n```")
+}
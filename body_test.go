@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMinifyBody(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"pretty JSON", "{\n    \"name\": \"a b\",\n    \"tags\": [ 1, 2 ]\n}", `{"name":"a b","tags":[1,2]}`},
+		{"not JSON", "name: a\n  b", "name: a\n  b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := parseCurlCommand(`curl --url http://api/users --header 'Content-Type: text/plain' --data '`+tt.body+`'`, parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			minifyBody(item)
+			if got := requestBody(item)["raw"]; got != tt.want {
+				t.Errorf("raw = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONBodyIsKeptAsText(t *testing.T) {
+	stream := "{\"id\":1}\n{\"id\":2}\n"
+	for _, contentType := range []string{"application/x-ndjson", "application/json"} {
+		item, err := parseCurlCommand("curl http://api/events -H 'Content-Type: "+contentType+"' --data-binary $'{\"id\":1}\\n{\"id\":2}\\n'", parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := requestBody(item)
+		if body["raw"] != stream {
+			t.Errorf("%s: raw = %q, want the stream unchanged", contentType, body["raw"])
+		}
+		options, _ := body["options"].(map[string]interface{})
+		raw, _ := options["raw"].(map[string]interface{})
+		if raw["language"] != "text" {
+			t.Errorf("%s: language = %v, want text", contentType, raw["language"])
+		}
+	}
+	if got := bodyLanguage("application/json", `{"id":1}`); got != "json" {
+		t.Errorf("single document language = %q, want json", got)
+	}
+}
+
+func TestChunkedBodyIsDechunked(t *testing.T) {
+	item, err := parseCurlCommand("curl http://api/upload -H 'Transfer-Encoding: chunked' -H 'Content-Length: 26' -H 'Content-Type: text/plain' --data-binary $'7\\r\\nchunked\\r\\n5\\r\\n body\\r\\n0\\r\\n\\r\\n'", parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requestBody(item)["raw"]; got != "chunked body" {
+		t.Errorf("raw = %q, want the chunks joined", got)
+	}
+	if got, want := headerList(item), "Content-Type: text/plain"; got != want {
+		t.Errorf("headers =\n%s\nwant\n%s without the framing headers", got, want)
+	}
+
+	for _, raw := range []string{"not chunked", "5\r\nab"} {
+		if got := dechunkBody(raw); got != raw {
+			t.Errorf("dechunkBody(%q) = %q, want it unchanged", raw, got)
+		}
+	}
+}
+
+func TestRedactBodyMasksPasswords(t *testing.T) {
+	body := "{\n  \"user\": \"a\",\n  \"Password\" : \"hunter2\",\n  \"nested\": {\"password\": {\"old\": \"x\"}, \"tags\": [\"password\"]},\n  \"tokens\": [{\"token\": 1}]\n}"
+	item, err := parseCurlCommand(`curl http://api/login -H 'Content-Type: application/json' --data-raw '`+body+`'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactBody(item, map[string]bool{"password": true, "token": true})
+	want := "{\n  \"user\": \"a\",\n  \"Password\" : \"***\",\n  \"nested\": {\"password\": \"***\", \"tags\": [\"password\"]},\n  \"tokens\": [{\"token\": \"***\"}]\n}"
+	if got := requestBody(item)["raw"]; got != want {
+		t.Errorf("raw =\n%s\nwant\n%s", got, want)
+	}
+
+	text, err := parseCurlCommand(`curl http://api/login -H 'Content-Type: text/plain' --data-raw 'password: hunter2'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactBody(text, map[string]bool{"password": true})
+	if got := requestBody(text)["raw"]; got != "password: hunter2" {
+		t.Errorf("non-JSON body = %q, want it untouched", got)
+	}
+}
+
+func TestDescribeBodySchema(t *testing.T) {
+	item, err := parseCurlCommand(`curl http://api/users -H 'Content-Type: application/json' -d '{"name":"a","age":3,"score":1.5,"admin":false,"tags":["x"],"address":{"zip":null},"roles":[]}'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	describeBodySchema(item)
+	description, _ := item["request"].(map[string]interface{})["description"].(string)
+	const prefix = "Request body schema:\n\n```json\n"
+	if !strings.HasPrefix(description, prefix) || !strings.HasSuffix(description, "\n```") {
+		t.Fatalf("description = %q, want a fenced JSON schema", description)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(description, prefix), "\n```")), &schema); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"address", "admin", "age", "name", "roles", "score", "tags"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string"},
+			"age":   map[string]interface{}{"type": "integer"},
+			"score": map[string]interface{}{"type": "number"},
+			"admin": map[string]interface{}{"type": "boolean"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"address": map[string]interface{}{"type": "object", "required": []interface{}{"zip"},
+				"properties": map[string]interface{}{"zip": map[string]interface{}{"type": "null"}}},
+			"roles": map[string]interface{}{"type": "array"},
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("schema = %v\nwant %v", schema, want)
+	}
+
+	text, err := parseCurlCommand(`curl http://api/users -H 'Content-Type: text/plain' -d 'not json'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	describeBodySchema(text)
+	if description, ok := text["request"].(map[string]interface{})["description"]; ok {
+		t.Errorf("non-JSON body got description %v", description)
+	}
+}
+
+func TestPrettyBodiesForcesTheLanguage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest(`curl http://api/soap -H 'Content-Type: application/json' -d '{"Envelope":{}}'`),
+		"test-set-0/tests/test-2.yaml": keployTest(`curl http://api/users`),
+	}
+	languages := func(pretty string) []interface{} {
+		opts := testOptions()
+		opts.prettyBodies = pretty
+		got := []interface{}{}
+		forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+			options, _ := testBody(item)["options"].(map[string]interface{})
+			raw, _ := options["raw"].(map[string]interface{})
+			got = append(got, raw["language"])
+		})
+		return got
+	}
+	if got := languages(""); got[0] != "json" || got[1] != nil {
+		t.Errorf("languages = %v, want json from the content type, then none", got)
+	}
+	if got := languages("xml"); got[0] != "xml" || got[1] != nil {
+		t.Errorf("-pretty-bodies xml languages = %v, want xml on the body only", got)
+	}
+	if out, code := runMain(t, t.TempDir(), "-pretty-bodies", "yaml"); code != 2 || !strings.Contains(out, "Unknown -pretty-bodies language") {
+		t.Errorf("exit %d, output %q; want an unknown language rejected", code, out)
+	}
+}
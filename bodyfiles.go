@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// bodyFileExtensions maps raw body languages to the extension of the file
+// -split-bodies-to-files writes them to.
+var bodyFileExtensions = map[string]string{
+	"json":       ".json",
+	"xml":        ".xml",
+	"html":       ".html",
+	"javascript": ".js",
+}
+
+// bodyFile is a body written by -split-bodies-to-files; src is its
+// slash-separated path relative to the collection referencing it.
+type bodyFile struct {
+	src  string
+	data []byte
+}
+
+// externalizeBodies writes every non-empty raw body to its own file in the
+// bodies directory next to outputFile and turns the request's body into a
+// Postman file body referencing it, relative to the output, so the
+// collection stays small and bodies diff on their own in version control.
+// Body files left by an earlier run are removed first, so none goes stale.
+// It returns the bodies it wrote.
+func externalizeBodies(items []interface{}, outputFile string) ([]bodyFile, error) {
+	dir := filepath.Join(filepath.Dir(outputFile), "bodies")
+	if err := removeBodyFiles(dir); err != nil {
+		return nil, err
+	}
+	written := []bodyFile{}
+	var err error
+	forEachRequest(items, func(item map[string]interface{}) {
+		body := requestBody(item)
+		raw, _ := body["raw"].(string)
+		if err != nil || body["mode"] != "raw" || raw == "" {
+			return
+		}
+		if len(written) == 0 {
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return
+			}
+		}
+		language := ""
+		if options, ok := body["options"].(map[string]interface{}); ok {
+			if rawOptions, ok := options["raw"].(map[string]interface{}); ok {
+				language, _ = rawOptions["language"].(string)
+			}
+		}
+		extension, ok := bodyFileExtensions[language]
+		if !ok {
+			extension = ".txt"
+		}
+		name, _ := item["name"].(string)
+		fileName := fmt.Sprintf("%03d-%s%s", len(written)+1, bodyFileName(name), extension)
+		if err = os.WriteFile(filepath.Join(dir, fileName), []byte(raw), 0644); err != nil {
+			return
+		}
+		src := path.Join("bodies", fileName)
+		written = append(written, bodyFile{src: src, data: []byte(raw)})
+		request := item["request"].(map[string]interface{})
+		request["body"] = map[string]interface{}{
+			"mode": "file",
+			"file": map[string]interface{}{"src": src},
+		}
+	})
+	return written, err
+}
+
+// removeBodyFiles deletes the NNN-name files externalizeBodies writes from
+// dir, leaving anything else there alone.
+func removeBodyFiles(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !reBodyFileName.MatchString(entry.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var reBodyFileName = regexp.MustCompile(`^[0-9]{3,}-.+`)
+
+// rebaseBodyFiles returns a copy of items for a collection written to a
+// different directory than the output, with every file body's src prefixed
+// by base, the output directory relative to that one. items itself is left
+// untouched.
+func rebaseBodyFiles(items []interface{}, base string) []interface{} {
+	rebased := make([]interface{}, len(items))
+	for i, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			rebased[i] = v
+			continue
+		}
+		copied := map[string]interface{}{}
+		for key, value := range item {
+			copied[key] = value
+		}
+		if children, ok := item["item"].([]interface{}); ok {
+			copied["item"] = rebaseBodyFiles(children, base)
+		}
+		if request, ok := item["request"].(map[string]interface{}); ok {
+			body, _ := request["body"].(map[string]interface{})
+			file, _ := body["file"].(map[string]interface{})
+			if src, ok := file["src"].(string); ok && body["mode"] == "file" {
+				copiedRequest := map[string]interface{}{}
+				for key, value := range request {
+					copiedRequest[key] = value
+				}
+				copiedRequest["body"] = map[string]interface{}{
+					"mode": "file",
+					"file": map[string]interface{}{"src": path.Join(base, src)},
+				}
+				copied["request"] = copiedRequest
+			}
+		}
+		rebased[i] = copied
+	}
+	return rebased
+}
+
+// relativeDir returns the slash-separated path of directory to relative to
+// directory from.
+func relativeDir(from, to string) (string, error) {
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return "", err
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absFrom, absTo)
+	return filepath.ToSlash(rel), err
+}
+
+// bodyFileName reduces a request name to characters safe in file names on
+// every platform.
+func bodyFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if safe = strings.Trim(safe, "._"); safe == "" {
+		return "body"
+	}
+	return safe
+}
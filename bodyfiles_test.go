@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// bodyFileTests records one JSON body, one text body and one request
+// without a body, in two test-sets.
+var bodyFileTests = fstest.MapFS{
+	"test-set-0/tests/test-1.yaml": keployTest(`curl -X POST http://api/users -H 'Content-Type: application/json' -d '{"name":"a"}'`),
+	"test-set-0/tests/test-2.yaml": keployTest("curl http://api/users"),
+	"test-set-1/tests/test-1.yaml": keployTest("curl -X PUT http://api/notes/1 -H 'Content-Type: text/plain' -d 'hello'"),
+}
+
+// fileBodySources lists the src of every file body in items.
+func fileBodySources(items []interface{}) []string {
+	sources := []string{}
+	forEachRequest(items, func(item map[string]interface{}) {
+		body := testBody(item)
+		if file, ok := body["file"].(map[string]interface{}); ok && body["mode"] == "file" {
+			sources = append(sources, file["src"].(string))
+		}
+	})
+	return sources
+}
+
+func TestBodiesAreExternalizedAndReferenced(t *testing.T) {
+	opts := testOptions()
+	opts.splitBodies = true
+	opts.output = filepath.Join(t.TempDir(), "out", "collection.json")
+	if err := os.MkdirAll(filepath.Dir(opts.output), 0755); err != nil {
+		t.Fatal(err)
+	}
+	collection := generateTestCollection(t, bodyFileTests, opts)
+
+	sources := fileBodySources(collection.Items)
+	want := []string{"bodies/001-users.json", "bodies/002-notes-1.txt"}
+	if strings.Join(sources, " ") != strings.Join(want, " ") {
+		t.Fatalf("file bodies = %v, want %v", sources, want)
+	}
+	contents := map[string]string{want[0]: `{"name":"a"}`, want[1]: "hello"}
+	for src, content := range contents {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(opts.output), filepath.FromSlash(src)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q (err %v), want %q", src, data, err, content)
+		}
+	}
+	if data, _ := os.ReadFile(opts.output); strings.Contains(string(data), "hello") {
+		t.Error("the collection still holds a body inline")
+	}
+}
+
+func TestSplitCollectionsReferenceBodiesFromTheirDirectory(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions()
+	opts.splitBodies = true
+	opts.output = filepath.Join(dir, "collection.json")
+	opts.split = filepath.Join(dir, "split", "parts")
+	collection := generateTestCollection(t, bodyFileTests, opts)
+	if got := strings.Join(fileBodySources(collection.Items), " "); got != "bodies/001-users.json bodies/002-notes-1.txt" {
+		t.Errorf("main collection file bodies = %s, want them unchanged by -split", got)
+	}
+
+	for file, want := range map[string]string{"test-set-0.json": "../../bodies/001-users.json", "test-set-1.json": "../../bodies/002-notes-1.txt"} {
+		data, err := os.ReadFile(filepath.Join(opts.split, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var part PostmanCollection
+		if err := json.Unmarshal(data, &part); err != nil {
+			t.Fatal(err)
+		}
+		sources := fileBodySources(part.Items)
+		if len(sources) != 1 || sources[0] != want {
+			t.Errorf("%s: file bodies = %v, want %s", file, sources, want)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(opts.split, filepath.FromSlash(sources[0]))); err != nil {
+			t.Errorf("%s: %s does not resolve: %v", file, sources[0], err)
+		}
+	}
+}
+
+func TestBundleIncludesBodies(t *testing.T) {
+	opts := testOptions()
+	opts.splitBodies = true
+	opts.output = filepath.Join(t.TempDir(), "collection.json")
+	opts.bundle = filepath.Join(t.TempDir(), "bundle.zip")
+	generateTestCollection(t, bodyFileTests, opts)
+
+	zr, err := zip.OpenReader(opts.bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := map[string]string{}
+	names := []string{}
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = string(data)
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+	want := "Atlantis.postman_collection.json Atlantis.postman_environment.json bodies/001-users.json bodies/002-notes-1.txt"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("bundle entries = %s, want %s", got, want)
+	}
+	if entries["bodies/001-users.json"] != `{"name":"a"}` || entries["bodies/002-notes-1.txt"] != "hello" {
+		t.Errorf("bundled bodies = %q, %q", entries["bodies/001-users.json"], entries["bodies/002-notes-1.txt"])
+	}
+	var bundled PostmanCollection
+	if err := json.Unmarshal([]byte(entries["Atlantis.postman_collection.json"]), &bundled); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range fileBodySources(bundled.Items) {
+		if _, ok := entries[src]; !ok {
+			t.Errorf("bundled collection references %s, which is not in the zip", src)
+		}
+	}
+}
+
+func TestS3UploadIncludesBodies(t *testing.T) {
+	stub := &stubS3{secret: "SECRETKEY", objects: map[string]string{}, types: map[string]string{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRETKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	opts := testOptions()
+	opts.splitBodies = true
+	opts.output = filepath.Join(t.TempDir(), "collection.json")
+	opts.s3 = "s3://my-bucket/ci/collection.json"
+	generateTestCollection(t, bodyFileTests, opts)
+
+	want := map[string]string{
+		"/my-bucket/ci/bodies/001-users.json":  `{"name":"a"}`,
+		"/my-bucket/ci/bodies/002-notes-1.txt": "hello",
+	}
+	for object, content := range want {
+		if got := stub.objects[object]; got != content {
+			t.Errorf("%s = %q, want %q", object, got, content)
+		}
+	}
+	if _, ok := stub.objects["/my-bucket/ci/collection.json"]; !ok || len(stub.objects) != 3 {
+		t.Errorf("uploaded objects = %v, want the collection and its two bodies", stub.objects)
+	}
+	if got := stub.types["/my-bucket/ci/bodies/001-users.json"]; got != "application/json" {
+		t.Errorf("body Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestStaleBodyFilesAreRemoved(t *testing.T) {
+	opts := testOptions()
+	opts.splitBodies = true
+	opts.output = filepath.Join(t.TempDir(), "collection.json")
+	bodies := filepath.Join(filepath.Dir(opts.output), "bodies")
+	generateTestCollection(t, bodyFileTests, opts)
+	if err := os.WriteFile(filepath.Join(bodies, "README.md"), []byte("kept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second run records one body fewer, under a different name
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest(`curl -X POST http://api/orders -H 'Content-Type: application/json' -d '{"id":1}'`),
+	}
+	generateTestCollection(t, fsys, opts)
+	entries, err := os.ReadDir(bodies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, " "); got != "001-orders.json README.md" {
+		t.Errorf("bodies = %s, want only this run's body and the unrelated file", got)
+	}
+
+	// A run without bodies clears the old ones too
+	generateTestCollection(t, fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}, opts)
+	if entries, _ := os.ReadDir(bodies); len(entries) != 1 {
+		t.Errorf("%d files left after a run without bodies, want only README.md", len(entries))
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+var (
+	stdinOnce sync.Once
+	stdinData string
+)
+
+// toolStdin returns what was piped into goPost, read once and shared by every
+// --data @- request that has no body recorded in its test. An interactive
+// terminal is never read, so a missing pipe cannot block the run.
+func toolStdin() string {
+	stdinOnce.Do(func() {
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+			return
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println("Error reading stdin:", err)
+			return
+		}
+		stdinData = string(data)
+	})
+	return stdinData
+}
+
+// buildContext holds the inputs loaded once per run and shared read-only by
+// every worker, plus noise, which each test-set fills in on its own copy.
+type buildContext struct {
+	statusMapping map[string]statusAssertions
+	ignore        ignoreList
+	noise         noiseFields
+}
+
+// testSetResult is the outcome of converting one test-set directory. A nil
+// folder means the test-set was skipped.
+type testSetResult struct {
+	folder    map[string]interface{}
+	endpoints []endpoint
+	tests     int
+	skipped   []skippedTest
+	warnings  []string
+}
+
+// skipError marks a test left out of the collection, giving the reason.
+type skipError struct {
+	reason error
+}
+
+func (e skipError) Error() string {
+	return e.reason.Error()
+}
+
+// buildTestSets converts the named test-sets using up to opts.parallel
+// workers. Each worker only writes its own result slot, and the results come
+// back in the order the test-sets were given, so the output is the same as a
+// sequential build.
+func buildTestSets(fsys fs.FS, names []string, opts options, ctx buildContext) ([]testSetResult, error) {
+	workers := opts.parallel
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]testSetResult, len(names))
+	errs := make([]error, len(names))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = buildTestSet(fsys, names[i], opts, ctx)
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// buildTestSet converts the tests of a single test-set into a Postman folder.
+// Subdirectories of its tests directory become nested folders.
+func buildTestSet(fsys fs.FS, name string, opts options, ctx buildContext) (testSetResult, error) {
+	result := testSetResult{}
+	testsDir := path.Join(name, "tests")
+	_, err := fs.Stat(fsys, testsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Println("No 'tests' subfolder in:", name)
+		result.warnings = append(result.warnings, "no tests directory in "+name)
+		return result, nil
+	}
+	ctx.noise, err = loadTestSetNoise(fsys, name)
+	if err != nil {
+		return result, fmt.Errorf("reading %s: %w", path.Join(name, "config.yaml"), err)
+	}
+	testCases, err := buildTestDir(fsys, testsDir, 0, opts, ctx, &result)
+	if err != nil {
+		return result, err
+	}
+	result.folder = map[string]interface{}{
+		"name": name,
+		"item": testCases,
+	}
+	if opts.docs {
+		result.folder["description"] = fmt.Sprintf("Requests recorded by keploy in %s.", name)
+	}
+	return result, nil
+}
+
+// buildTestDir converts the tests in dir, in recorded order, adding a folder
+// for every subdirectory that holds any. depth is how far dir lies below the
+// tests directory; subdirectories beyond -max-depth are not read.
+func buildTestDir(fsys fs.FS, dir string, depth int, opts options, ctx buildContext, result *testSetResult) ([]interface{}, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		fmt.Println("Error reading 'tests' directory:", err)
+		result.warnings = append(result.warnings, fmt.Sprintf("reading %s: %v", dir, err))
+		return []interface{}{}, nil
+	}
+	sortEntries(entries)
+	testCases := []interface{}{}
+	for _, entry := range entries {
+		entryPath := path.Join(dir, entry.Name())
+		if ctx.ignore.matches(entryPath) {
+			continue
+		}
+		if entry.IsDir() {
+			if opts.maxDepth >= 0 && depth >= opts.maxDepth {
+				fmt.Println("Skipping", entryPath+": deeper than -max-depth")
+				result.skipped = append(result.skipped, skippedTest{entryPath, "deeper than -max-depth"})
+				continue
+			}
+			children, err := buildTestDir(fsys, entryPath, depth+1, opts, ctx, result)
+			if err != nil {
+				return nil, err
+			}
+			if len(children) > 0 {
+				testCases = append(testCases, map[string]interface{}{
+					"name": entry.Name(),
+					"item": children,
+				})
+			}
+			continue
+		}
+		if path.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+		result.tests++
+		requestJSON, status, err := buildTestCase(fsys, entryPath, opts, ctx)
+		var skipped skipError
+		if errors.As(err, &skipped) {
+			fmt.Printf("Skipping %s: %v\n", entryPath, skipped.reason)
+			result.skipped = append(result.skipped, skippedTest{entryPath, skipped.reason.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.selfCheck {
+			for _, loss := range roundTripLosses(requestJSON) {
+				fmt.Printf("Warning: %s: %s\n", entryPath, loss)
+				result.warnings = append(result.warnings, fmt.Sprintf("%s: %s", entryPath, loss))
+			}
+		}
+		testCases = append(testCases, requestJSON)
+		result.endpoints = append(result.endpoints, newEndpoint(requestJSON, status))
+		requestJSON[recordedStatusKey] = status
+	}
+	return testCases, nil
+}
+
+// buildTestCase converts one recorded test into a Postman request, returning
+// it with its recorded status code. Tests that cannot be converted return a
+// skipError, unless -strict-curl makes a bad curl command fatal.
+func buildTestCase(fsys fs.FS, filePath string, opts options, ctx buildContext) (map[string]interface{}, int, error) {
+	// Read the YAML file
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, 0, skipError{fmt.Errorf("reading file: %w", err)}
+	}
+	// Editors on Windows may save the file with a UTF-8 byte order
+	// mark, which yaml.Unmarshal rejects
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	// Parse the YAML file (assuming it's a map for simplicity)
+	var yamlData map[string]interface{}
+	err = yaml.Unmarshal(data, &yamlData)
+	if err != nil {
+		return nil, 0, skipError{fmt.Errorf("parsing YAML: %w", err)}
+	}
+	curl, ok := findCurl(yamlData, opts.curlFields)
+	if !ok {
+		return nil, 0, skipError{errors.New("no curl command")}
+	}
+	curl, comments := splitCurlComments(curl)
+	if opts.windowsVars {
+		curl = windowsVarsToPostman(curl)
+	}
+	requestJSON, err := parseCurlCommand(curl, parseOptions{
+		defaultHost: recordedHost(yamlData),
+		stdinBody: func() string {
+			if body := yamlString(yamlData, "spec.req.body"); body != "" {
+				return body
+			}
+			return toolStdin()
+		},
+		preserveRawUrl: opts.preserveRawUrl,
+	})
+	if err != nil {
+		if opts.strictCurl {
+			return nil, 0, fmt.Errorf("%s: %w", filePath, err)
+		}
+		return nil, 0, skipError{err}
+	}
+	applyRecordedAuth(requestJSON, yamlData)
+	if opts.curlComments {
+		appendDescription(requestJSON, strings.Join(comments, "\n"))
+	}
+	if opts.sourcePath {
+		appendDescription(requestJSON, "Source: "+filePath)
+	}
+	if opts.nameFromSummary {
+		if summary := testSummary(yamlData); summary != "" {
+			requestJSON["name"] = summary
+		}
+	}
+	if opts.redactKeys != nil {
+		redactBody(requestJSON, opts.redactKeys)
+	}
+	if opts.minifyBodies {
+		minifyBody(requestJSON)
+	}
+	if opts.bodySchema {
+		describeBodySchema(requestJSON)
+	}
+	if opts.prettyBodies != "" {
+		setBodyLanguage(requestJSON, opts.prettyBodies)
+	}
+	if opts.normalizeHosts {
+		normalizeHost(requestJSON)
+	}
+	applyPathPrefix(requestJSON, opts.pathPrefix)
+	if opts.queryFromBody {
+		moveBodyToQuery(requestJSON)
+	}
+	if opts.mergeHeaders {
+		mergeDuplicateHeaders(requestJSON)
+	}
+	normalizeAcceptEncoding(requestJSON, opts.acceptEncoding)
+	applyHeaderTemplates(requestJSON, opts.headerTemplates)
+	if opts.queryArrayStyle != "" {
+		applyQueryArrayStyle(requestJSON, opts.queryArrayStyle)
+	}
+	if opts.timestampScript {
+		addTimestampScript(requestJSON)
+	}
+	status, hasStatus := yamlInt(yamlData, "spec.resp.status_code")
+	noise := testNoise(ctx.noise, yamlData)
+	if hasStatus && ctx.statusMapping != nil {
+		addScript(requestJSON, "test", statusTests(status, ctx.statusMapping, noise))
+	}
+	addScript(requestJSON, "test", assertionHeaderTests(yamlData, noise))
+	addScript(requestJSON, "test", responseTimeTests(yamlData, opts.latencyFactor))
+	exampleOnly := isExampleOnly(yamlData)
+	if opts.examples || opts.docs || exampleOnly {
+		if example := recordedExample(requestJSON, yamlData, opts.docs); example != nil {
+			requestJSON["response"] = []interface{}{example}
+		}
+	}
+	if exampleOnly {
+		markExampleOnly(requestJSON)
+	}
+	return requestJSON, status, nil
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testOptions returns the options a run with no flags uses, converting one
+// test-set at a time.
+func testOptions() options {
+	return options{
+		format:         "postman",
+		acceptEncoding: "keep",
+		dedupeBy:       signatureBody,
+		parallel:       1,
+		maxDepth:       -1,
+		curlFields:     strings.Split(defaultCurlFields, ","),
+		exporterId:     "132182772",
+		groupBy:        groupByTestSet,
+		name:           "Atlantis",
+	}
+}
+
+// keployTest renders a minimal keploy test file recording curl, followed by
+// any extra YAML lines.
+func keployTest(curl string, extra ...string) *fstest.MapFile {
+	lines := append([]string{"curl: " + strconv.Quote(curl)}, extra...)
+	return &fstest.MapFile{Data: []byte(strings.Join(lines, "\n") + "\n")}
+}
+
+// generateTestCollection runs generate over fsys, writing to a temporary
+// output unless opts names one, and returns the collection read back from it.
+func generateTestCollection(t *testing.T, fsys fs.FS, opts options) PostmanCollection {
+	t.Helper()
+	if opts.output == "" {
+		opts.output = filepath.Join(t.TempDir(), "output.json")
+	}
+	if err := generate(fsys, opts); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection PostmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("decoding %s: %v", opts.output, err)
+	}
+	return collection
+}
+
+// itemNames lists the names of items, descending into folders as "folder/name".
+func itemNames(items []interface{}, prefix string) []string {
+	names := []string{}
+	for _, v := range items {
+		item := v.(map[string]interface{})
+		name, _ := item["name"].(string)
+		if children, ok := item["item"].([]interface{}); ok {
+			names = append(names, itemNames(children, prefix+name+"/")...)
+			continue
+		}
+		names = append(names, prefix+name)
+	}
+	return names
+}
+
+func TestRequestsKeepRecordedOrderWithinFolders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-10/tests/test-1.yaml": keployTest("curl http://api/ten"),
+		"test-set-2/tests/test-10.yaml": keployTest("curl http://api/c"),
+		"test-set-2/tests/test-2.yaml":  keployTest("curl http://api/b"),
+		"test-set-2/tests/test-1.yaml":  keployTest("curl http://api/a"),
+	}
+	collection := generateTestCollection(t, fsys, testOptions())
+	got := strings.Join(itemNames(collection.Items, ""), " ")
+	if want := "test-set-2/a test-set-2/b test-set-2/c test-set-10/ten"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestGenerateFromZipArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, curl := range map[string]string{
+		"keploy/test-set-0/tests/test-1.yaml": "curl http://api/users",
+		"keploy/test-set-1/tests/test-1.yaml": "curl -X DELETE http://api/users/1",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(keployTest(curl).Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := generateTestCollection(t, keployRoot(zr), testOptions())
+	got := strings.Join(itemNames(collection.Items, ""), " ")
+	if want := "test-set-0/users test-set-1/users-1"; got != want {
+		t.Errorf("items = %s, want %s", got, want)
+	}
+}
+
+func TestStrictCurlFailsTheRun(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl --request GET"),
+	}
+	opts := testOptions()
+	if got := itemNames(generateTestCollection(t, fsys, opts).Items, ""); len(got) != 1 {
+		t.Errorf("without -strict-curl items = %v, want the bad test skipped", got)
+	}
+
+	opts.strictCurl = true
+	opts.output = filepath.Join(t.TempDir(), "output.json")
+	err := generate(fsys, opts)
+	if err == nil || !strings.Contains(err.Error(), "test-set-0/tests/test-2.yaml") {
+		t.Fatalf("generate = %v, want an error naming test-2.yaml", err)
+	}
+	if _, err := os.Stat(opts.output); err == nil {
+		t.Error("output written despite the failed run")
+	}
+}
+
+// testScript returns the lines of a decoded item's script for an event.
+func testScript(item map[string]interface{}, listen string) string {
+	events, _ := item["event"].([]interface{})
+	for _, v := range events {
+		event := v.(map[string]interface{})
+		if event["listen"] != listen {
+			continue
+		}
+		lines := []string{}
+		for _, line := range event["script"].(map[string]interface{})["exec"].([]interface{}) {
+			lines = append(lines, line.(string))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// firstRequest returns the first request item of a collection.
+func firstRequest(t *testing.T, collection PostmanCollection) map[string]interface{} {
+	t.Helper()
+	var first map[string]interface{}
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		if first == nil {
+			first = item
+		}
+	})
+	if first == nil {
+		t.Fatal("collection has no requests")
+	}
+	return first
+}
+
+func TestAssertionsBlockBecomesHeaderTests(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users",
+			"spec:",
+			"  assertions:",
+			"    header_equal:",
+			"      Content-Type: application/json",
+			"    header_contains:",
+			"      Cache-Control: no-store",
+			"    header_exists:",
+			"      - X-Request-Id",
+		),
+	}
+	script := testScript(firstRequest(t, generateTestCollection(t, fsys, testOptions())), "test")
+	for _, want := range []string{
+		`pm.response.to.have.header("Content-Type", "application/json");`,
+		`pm.expect(pm.response.headers.get("Cache-Control")).to.include("no-store");`,
+		`pm.response.to.have.header("X-Request-Id");`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("test script lacks %s:\n%s", want, script)
+		}
+	}
+}
+
+func TestRelativeUrlTakesHostFromMetadata(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl --url /api/users",
+			"spec:",
+			"  metadata:",
+			"    host: https://api.example.com",
+		),
+		"test-set-0/tests/test-2.yaml": keployTest("curl --url /api/orders -H 'Host: shop.local:8080'"),
+	}
+	got := []string{}
+	forEachRequest(generateTestCollection(t, fsys, testOptions()).Items, func(item map[string]interface{}) {
+		got = append(got, requestRawUrl(testRequest(item)))
+	})
+	if want := "https://api.example.com/api/users http://shop.local:8080/api/orders"; strings.Join(got, " ") != want {
+		t.Errorf("urls = %v, want %s", got, want)
+	}
+}
+
+func TestNameFromSummary(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users/1", "summary: Fetch a user"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/orders", "spec:", "  summary: List orders"),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/carts", "summary: '  '"),
+	}
+	opts := testOptions()
+	if got := strings.Join(itemNames(generateTestCollection(t, fsys, opts).Items, ""), ","); got != "test-set-0/users-1,test-set-0/orders,test-set-0/carts" {
+		t.Errorf("without -name-from-summary names = %s", got)
+	}
+	opts.nameFromSummary = true
+	if got := strings.Join(itemNames(generateTestCollection(t, fsys, opts).Items, ""), ","); got != "test-set-0/Fetch a user,test-set-0/List orders,test-set-0/carts" {
+		t.Errorf("with -name-from-summary names = %s", got)
+	}
+}
+
+// TestParallelBuildMatchesSequential converts a large fixture with many
+// workers; run it with go test -race to check the workers share nothing.
+func TestParallelBuildMatchesSequential(t *testing.T) {
+	fsys := fstest.MapFS{}
+	names := []string{}
+	for set := 0; set < 20; set++ {
+		name := fmt.Sprintf("test-set-%d", set)
+		names = append(names, name)
+		for test := 0; test < 30; test++ {
+			fsys[fmt.Sprintf("%s/tests/test-%d.yaml", name, test)] = keployTest(
+				fmt.Sprintf("curl -X POST http://api/sets/%d/tests/%d -H 'Accept: */*' -d '{\"n\":%d}'", set, test, test),
+				"spec:", "  resp:", fmt.Sprintf("    status_code: %d", 200+test%3),
+			)
+		}
+	}
+	build := func(parallel int) string {
+		opts := testOptions()
+		opts.parallel = parallel
+		results, err := buildTestSets(fsys, names, opts, buildContext{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		folders := []interface{}{}
+		tests := 0
+		for _, result := range results {
+			folders = append(folders, result.folder)
+			tests += result.tests
+		}
+		if tests != 600 {
+			t.Errorf("parallel %d converted %d tests, want 600", parallel, tests)
+		}
+		encoded, err := json.Marshal(folders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(encoded)
+	}
+	if sequential, parallel := build(1), build(16); parallel != sequential {
+		t.Error("the parallel build differs from the sequential one")
+	}
+}
+
+func TestBOMPrefixedTestParses(t *testing.T) {
+	test := keployTest("curl http://api/users")
+	test.Data = append([]byte("\xef\xbb\xbf"), test.Data...)
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": test}
+	if got := itemNames(generateTestCollection(t, fsys, testOptions()).Items, ""); len(got) != 1 || got[0] != "test-set-0/users" {
+		t.Errorf("items = %v, want the BOM-prefixed test converted", got)
+	}
+}
+
+func TestCurlCommentsBecomeTheDescription(t *testing.T) {
+	curl := "# Creates a user\n# Needs an admin token\ncurl -X POST http://api/users \\\n  -d '{\"name\":\"a\"}'\n# Returns 201"
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest(curl)}
+	opts := testOptions()
+	if description, _ := testRequest(firstRequest(t, generateTestCollection(t, fsys, opts)))["description"].(string); description != "" {
+		t.Errorf("without -include-curl-comments description = %q", description)
+	}
+	opts.curlComments = true
+	request := testRequest(firstRequest(t, generateTestCollection(t, fsys, opts)))
+	if got, want := request["description"], "Creates a user\nNeeds an admin token\nReturns 201"; got != want {
+		t.Errorf("description = %q, want %q", got, want)
+	}
+	if request["method"] != "POST" || requestBody(map[string]interface{}{"request": request})["raw"] != `{"name":"a"}` {
+		t.Errorf("request = %v, want the commented curl parsed", request)
+	}
+}
+
+func TestSourcePathInTheDescription(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml":     keployTest("curl --resolve api:80:127.0.0.1 http://api/users"),
+		"test-set-0/tests/sub/test-2.yaml": keployTest("curl http://api/orders"),
+	}
+	opts := testOptions()
+	if description, _ := testRequest(firstRequest(t, generateTestCollection(t, fsys, opts)))["description"].(string); strings.Contains(description, "Source:") {
+		t.Errorf("without -source-path description = %q", description)
+	}
+	opts.sourcePath = true
+	descriptions := []string{}
+	forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+		description, _ := testRequest(item)["description"].(string)
+		descriptions = append(descriptions, description)
+	})
+	want := []string{
+		"Source: test-set-0/tests/sub/test-2.yaml",
+		"Recorded with curl --resolve api:80:127.0.0.1\n\nSource: test-set-0/tests/test-1.yaml",
+	}
+	if !reflect.DeepEqual(descriptions, want) {
+		t.Errorf("descriptions = %q, want %q", descriptions, want)
+	}
+}
+
+func TestIgnoreFileExcludesTests(t *testing.T) {
+	fsys := fstest.MapFS{
+		".goPostignore":                 {Data: []byte("# flaky recordings\ntest-set-0/tests/test-2.yaml\ntest-set-1/\n*-draft.yaml\n")},
+		"test-set-0/tests/test-1.yaml":  keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml":  keployTest("curl http://api/flaky"),
+		"test-set-0/tests/x-draft.yaml": keployTest("curl http://api/draft"),
+		"test-set-1/tests/test-1.yaml":  keployTest("curl http://api/ignored"),
+	}
+	if got := strings.Join(itemNames(generateTestCollection(t, fsys, testOptions()).Items, ""), " "); got != "test-set-0/users" {
+		t.Errorf("items = %s, want only test-set-0/users", got)
+	}
+}
+
+func TestCurlStoredAsAListOfLines(t *testing.T) {
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": &fstest.MapFile{Data: []byte(
+		"curl:\n" +
+			"  - curl -X PUT http://api/users/1 \\\n" +
+			"  - \"  -H 'Content-Type: application/json' \\\\\"\n" +
+			"  - \"  -d '{\\\"name\\\":\\\"a\\\"}'\"\n")}}
+	request := testRequest(firstRequest(t, generateTestCollection(t, fsys, testOptions())))
+	if request["method"] != "PUT" || requestRawUrl(request) != "http://api/users/1" {
+		t.Errorf("request = %v, want PUT http://api/users/1", request)
+	}
+	if body := requestBody(map[string]interface{}{"request": request}); body["raw"] != `{"name":"a"}` {
+		t.Errorf("body = %v, want the body from the last line", body)
+	}
+}
+
+func TestSubdirectoriesBecomeNestedFolders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml":            keployTest("curl http://api/users"),
+		"test-set-0/tests/auth/test-1.yaml":       keployTest("curl http://api/login"),
+		"test-set-0/tests/auth/admin/test-1.yaml": keployTest("curl http://api/sudo"),
+		"test-set-0/tests/empty/notes.txt":        &fstest.MapFile{Data: []byte("not a test")},
+		"test-set-0/tests/empty/deeper/notes.txt": &fstest.MapFile{Data: []byte("not a test")},
+	}
+	collection := generateTestCollection(t, fsys, testOptions())
+	got := strings.Join(itemNames(collection.Items, ""), " ")
+	if want := "test-set-0/auth/admin/sudo test-set-0/auth/login test-set-0/users"; got != want {
+		t.Errorf("items = %s, want %s", got, want)
+	}
+	if children := collection.Items[0].(map[string]interface{})["item"].([]interface{}); len(children) != 2 {
+		t.Errorf("test-set-0 holds %d items, want auth and users without a folder for empty", len(children))
+	}
+}
+
+func TestCurlFoundAtTheThirdCandidatePath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": &fstest.MapFile{Data: []byte("spec:\n  summary: no curl here\nrequest:\n  curl: curl -X PATCH http://api/users/1\n")},
+		"test-set-0/tests/test-2.yaml": &fstest.MapFile{Data: []byte("spec:\n  curl: curl http://api/spec\nrequest:\n  curl: curl http://api/request\n")},
+	}
+	collection := generateTestCollection(t, fsys, testOptions())
+	got := []string{}
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		request := testRequest(item)
+		got = append(got, fmt.Sprintf("%v %s", request["method"], requestRawUrl(request)))
+	})
+	if want := "PATCH http://api/users/1, GET http://api/spec"; strings.Join(got, ", ") != want {
+		t.Errorf("requests = %s, want %s", strings.Join(got, ", "), want)
+	}
+
+	opts := testOptions()
+	opts.curlFields = []string{"request.curl"}
+	collection = generateTestCollection(t, fsys, opts)
+	if request := testRequest(collection.Items[0].(map[string]interface{})["item"].([]interface{})[1].(map[string]interface{})); requestRawUrl(request) != "http://api/request" {
+		t.Errorf("-curl-fields request.curl read %s", requestRawUrl(request))
+	}
+}
+
+func TestMaxDepthSkipsDeeperDirectories(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml":       keployTest("curl http://api/top"),
+		"test-set-0/tests/a/test-1.yaml":     keployTest("curl http://api/one"),
+		"test-set-0/tests/a/b/test-1.yaml":   keployTest("curl http://api/two"),
+		"test-set-0/tests/a/b/c/test-1.yaml": keployTest("curl http://api/three"),
+	}
+	tests := map[int]string{
+		-1: "test-set-0/a/b/c/three test-set-0/a/b/two test-set-0/a/one test-set-0/top",
+		0:  "test-set-0/top",
+		2:  "test-set-0/a/b/two test-set-0/a/one test-set-0/top",
+	}
+	for depth, want := range tests {
+		opts := testOptions()
+		opts.maxDepth = depth
+		opts.report = filepath.Join(t.TempDir(), "report.json")
+		if got := strings.Join(itemNames(generateTestCollection(t, fsys, opts).Items, ""), " "); got != want {
+			t.Errorf("-max-depth %d: items = %s, want %s", depth, got, want)
+		}
+		report, err := os.ReadFile(opts.report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skipped := strings.Contains(string(report), "deeper than -max-depth"); skipped != (depth >= 0) {
+			t.Errorf("-max-depth %d: report lists a skipped directory = %v", depth, skipped)
+		}
+	}
+}
+
+func TestCollectionAuthFromEnv(t *testing.T) {
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	t.Setenv("GOPOST_TEST_TOKEN", "s3cret")
+	opts := testOptions()
+	opts.authEnv = "GOPOST_TEST_TOKEN"
+	collection := generateTestCollection(t, fsys, opts)
+	if bearer, _ := collection.Auth["bearer"].([]interface{}); collection.Auth["type"] != "bearer" || len(bearer) != 1 ||
+		bearer[0].(map[string]interface{})["value"] != "{{token}}" {
+		t.Errorf("collection auth = %v, want bearer {{token}}", collection.Auth)
+	}
+	if got := fmt.Sprint(collection.Variables); got != "[map[key:token value:s3cret]]" {
+		t.Errorf("variables = %s, want token defaulting to the env var", got)
+	}
+
+	os.Unsetenv("GOPOST_TEST_TOKEN")
+	collection = generateTestCollection(t, fsys, opts)
+	if got := fmt.Sprint(collection.Variables); got != "[map[key:token value:]]" || collection.Auth["type"] != "bearer" {
+		t.Errorf("unset env var: auth = %v, variables = %s", collection.Auth, got)
+	}
+
+	opts.authEnv = ""
+	if collection = generateTestCollection(t, fsys, opts); collection.Auth != nil || len(collection.Variables) != 0 {
+		t.Errorf("without the flag: auth = %v, variables = %v", collection.Auth, collection.Variables)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+)
+
+// buildEnvironment creates a Postman environment exposing the collection's
+// variables, so they can be edited per environment after import.
+func buildEnvironment(collection PostmanCollection) map[string]interface{} {
+	values := []map[string]interface{}{}
+	for _, variable := range collection.Variables {
+		values = append(values, map[string]interface{}{
+			"key":     variable["key"],
+			"value":   variable["value"],
+			"type":    "default",
+			"enabled": true,
+		})
+	}
+	return map[string]interface{}{
+		"id":                      newUUID(),
+		"name":                    collection.Info.Name,
+		"values":                  values,
+		"_postman_variable_scope": "environment",
+	}
+}
+
+// writeBundle packages the collection and its environment into one zip,
+// using the file names Postman gives its own exports. Bodies written by
+// -split-bodies-to-files go in alongside, at the paths the collection
+// references them by.
+func writeBundle(path string, collection PostmanCollection, bodies []bodyFile) error {
+	collectionData, err := json.MarshalIndent(collection, "", "    ")
+	if err != nil {
+		return err
+	}
+	environmentData, err := json.MarshalIndent(buildEnvironment(collection), "", "    ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	baseName := strings.ReplaceAll(collection.Info.Name, "/", "_")
+	zw := zip.NewWriter(file)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{baseName + ".postman_collection.json", collectionData},
+		{baseName + ".postman_environment.json", environmentData},
+	}
+	for _, body := range bodies {
+		entries = append(entries, struct {
+			name string
+			data []byte
+		}{body.src, body.data})
+	}
+	for _, entry := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     entry.name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBundleHoldsCollectionAndEnvironment(t *testing.T) {
+	collection := testCollection(t, `curl http://api/users`)
+	collection.Variables = []map[string]string{{"key": "session", "value": "abc"}}
+	path := filepath.Join(t.TempDir(), "out.zip")
+	if err := writeBundle(path, collection, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := map[string]map[string]interface{}{}
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := map[string]interface{}{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", file.Name, err)
+		}
+		entries[file.Name] = decoded
+	}
+	if len(entries) != 2 {
+		t.Fatalf("bundle has %d entries, want 2", len(entries))
+	}
+	if info, _ := entries["Atlantis.postman_collection.json"]["info"].(map[string]interface{}); info["name"] != "Atlantis" {
+		t.Errorf("collection entry info = %v", info)
+	}
+	environment := entries["Atlantis.postman_environment.json"]
+	values, _ := environment["values"].([]interface{})
+	if environment["_postman_variable_scope"] != "environment" || len(values) != 1 || values[0].(map[string]interface{})["value"] != "abc" {
+		t.Errorf("environment entry = %v", environment)
+	}
+}
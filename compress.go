@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+)
+
+// gzipOutput compresses data for writing as name.gz; the archived file name is
+// recorded so tools like gunzip -N restore the plain output name.
+func gzipOutput(name string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Name = filepath.Base(name)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGzipOutputDecompressesToThePlainOutput(t *testing.T) {
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	dir := t.TempDir()
+	opts := testOptions()
+	opts.collectionId = "0b7d2f6c-6d1e-4a8e-9f44-5f0a45d6b6a1"
+	opts.output = filepath.Join(dir, "output.json")
+	if err := generate(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts.output = filepath.Join(dir, "compressed.json")
+	opts.gzip = true
+	if err := generate(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := os.ReadFile(opts.output + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Name != "compressed.json" {
+		t.Errorf("gzip header name = %q, want compressed.json", reader.Name)
+	}
+	if !bytes.Equal(decompressed, plain) {
+		t.Errorf("decompressed output differs from the plain output:\n%s\nwant\n%s", decompressed, plain)
+	}
+}
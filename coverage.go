@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// buildCoverage summarises the recorded endpoints for -coverage: one row per
+// unique method and path, in order of first recording, with how many
+// requests hit it, followed by the totals.
+func buildCoverage(endpoints []endpoint) []byte {
+	type coverageRow struct {
+		method, path string
+		requests     int
+	}
+	rows := []*coverageRow{}
+	seen := map[string]*coverageRow{}
+	for _, e := range endpoints {
+		key := e.method + " " + e.path
+		row, ok := seen[key]
+		if !ok {
+			row = &coverageRow{method: e.method, path: e.path}
+			seen[key] = row
+			rows = append(rows, row)
+		}
+		row.requests++
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tREQUESTS")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", row.method, row.path, row.requests)
+	}
+	w.Flush()
+	fmt.Fprintf(&buf, "%d unique endpoints, %d requests, %d duplicates\n", len(rows), len(endpoints), len(endpoints)-len(rows))
+	return buf.Bytes()
+}
+
+// writeCoverage writes the coverage summary to path, or to standard output
+// when path is "-".
+func writeCoverage(path string, endpoints []endpoint) error {
+	coverage := buildCoverage(endpoints)
+	if path == "-" {
+		_, err := os.Stdout.Write(coverage)
+		return err
+	}
+	return os.WriteFile(path, coverage, 0644)
+}
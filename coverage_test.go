@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCoverageCounts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users?page=1"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl -X POST http://api/users -d 'a=1'"),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/users?page=2"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api/users"),
+		"test-set-1/tests/test-2.yaml": keployTest("curl http://api"),
+	}
+	opts := testOptions()
+	opts.output = filepath.Join(t.TempDir(), "output.json")
+	opts.coverage = filepath.Join(t.TempDir(), "coverage.txt")
+	if err := generate(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(opts.coverage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "METHOD  PATH    REQUESTS\n" +
+		"GET     /users  3\n" +
+		"POST    /users  1\n" +
+		"GET     /       1\n" +
+		"3 unique endpoints, 5 requests, 2 duplicates\n"
+	if string(data) != want {
+		t.Errorf("coverage =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestCoverageToStandardOutput(t *testing.T) {
+	dir := t.TempDir()
+	tests := filepath.Join(dir, "recordings", "test-set-0", "tests")
+	if err := os.MkdirAll(tests, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tests, "test-1.yaml"), keployTest("curl http://api/users").Data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, code := runMain(t, dir, "-input", "recordings", "-coverage", "-")
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, out)
+	}
+	if want := "GET     /users  1\n1 unique endpoints, 1 requests, 0 duplicates\n"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want the coverage summary %q", out, want)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+)
+
+// endpoint is one discovered request in the -format csv inventory.
+type endpoint struct {
+	method string
+	path   string
+	status int
+	item   map[string]interface{}
+}
+
+// newEndpoint describes a parsed request item with its recorded status, or
+// a zero status when the test case has none.
+func newEndpoint(item map[string]interface{}, status int) endpoint {
+	request, _ := item["request"].(map[string]interface{})
+	method, _ := request["method"].(string)
+	path := ""
+	if parsedUrl, err := requestURL(request); err == nil {
+		path = parsedUrl.Path
+	}
+	if path == "" {
+		path = "/"
+	}
+	return endpoint{method: method, path: path, status: status, item: item}
+}
+
+// recordedStatusKey holds the status recorded for a request on its own item
+// while the collection is built, so it stays with the request through the
+// filters and any copies made of it. collectionEndpoints takes it off again
+// before the collection is rendered.
+const recordedStatusKey = "goPost:recordedStatus"
+
+// collectionEndpoints describes the requests left in items once -dedupe,
+// -base and the other filters have run, in collection order, each with the
+// status recorded for it, and removes the statuses from the items.
+func collectionEndpoints(items []interface{}) []endpoint {
+	endpoints := []endpoint{}
+	forEachRequest(items, func(item map[string]interface{}) {
+		status, _ := item[recordedStatusKey].(int)
+		delete(item, recordedStatusKey)
+		endpoints = append(endpoints, newEndpoint(item, status))
+	})
+	return endpoints
+}
+
+// buildCSV renders the endpoints as method,path,status rows under a header.
+func buildCSV(endpoints []endpoint) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"method", "path", "status"}); err != nil {
+		return nil, err
+	}
+	for _, e := range endpoints {
+		status := ""
+		if e.status != 0 {
+			status = strconv.Itoa(e.status)
+		}
+		if err := w.Write([]string{e.method, e.path, status}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// generateTestOutput runs generate over fsys and returns the output file.
+func generateTestOutput(t *testing.T, fsys fstest.MapFS, opts options) string {
+	t.Helper()
+	opts.output = filepath.Join(t.TempDir(), "output")
+	if err := generate(fsys, opts); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCSVRows(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users", "spec:", "  resp:", "    status_code: 200"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl -X POST http://api/users/:id/orders?page=2 -d '{}'", "spec:", "  resp:", "    status_code: 201"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api"),
+	}
+	opts := testOptions()
+	opts.format = "csv"
+	want := "method,path,status\nGET,/users,200\nPOST,/users/:id/orders,201\nGET,/,\n"
+	if got := generateTestOutput(t, fsys, opts); got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCSVRowsFollowTheFilters(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users", "spec:", "  resp:", "    status_code: 200"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/users", "spec:", "  resp:", "    status_code: 200"),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/orders", "spec:", "  resp:", "    status_code: 404"),
+	}
+	opts := testOptions()
+	opts.format = "csv"
+	opts.dedupe = true
+	opts.pathPrefix = "/v2"
+	want := "method,path,status\nGET,/v2/users,200\nGET,/v2/orders,404\n"
+	if got := generateTestOutput(t, fsys, opts); got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRecordedStatusSurvivesItemCopies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users", "spec:", "  resp:", "    status_code: 200"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/orders", "spec:", "  resp:", "    status_code: 404"),
+	}
+	collection := generateTestCollection(t, fsys, testOptions())
+	if data, err := json.Marshal(collection); err != nil || strings.Contains(string(data), recordedStatusKey) {
+		t.Errorf("the collection output carries the recorded statuses: %s", data)
+	}
+
+	// A copied item keeps its status, unlike a lookup by identity
+	original := map[string]interface{}{
+		"name":            "users",
+		"request":         map[string]interface{}{"method": "GET", "url": map[string]interface{}{"raw": "http://api/users"}},
+		recordedStatusKey: 201,
+	}
+	copied := map[string]interface{}{}
+	for key, value := range original {
+		copied[key] = value
+	}
+	endpoints := collectionEndpoints([]interface{}{map[string]interface{}{"name": "set", "item": []interface{}{copied}}})
+	if len(endpoints) != 1 || endpoints[0].status != 201 || endpoints[0].path != "/users" {
+		t.Errorf("endpoints = %+v, want GET /users with status 201", endpoints)
+	}
+	if _, ok := copied[recordedStatusKey]; ok {
+		t.Error("collectionEndpoints left the status on the item")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Signature granularities accepted by -dedupe-by.
+const (
+	signatureURL  = "url"
+	signatureBody = "body"
+	signatureFull = "full"
+)
+
+// requestSignature identifies a request so the same recorded call can be
+// recognised across test-sets or collections. Depending on by it covers the
+// method and URL, those plus the body, or the full request including headers
+// and auth.
+func requestSignature(item map[string]interface{}, by string) string {
+	method, rawUrl := "GET", ""
+	parts := []string{}
+	switch request := item["request"].(type) {
+	case string:
+		// Postman allows a bare URL string as a shorthand for a GET request
+		rawUrl = request
+	case map[string]interface{}:
+		if m, ok := request["method"].(string); ok && m != "" {
+			method = m
+		}
+		rawUrl = requestRawUrl(request)
+		if by == signatureURL {
+			break
+		}
+		if body, ok := request["body"].(map[string]interface{}); ok {
+			parts = append(parts, bodySignature(body))
+		}
+		if by == signatureFull {
+			parts = append(parts, headerLines(request)...)
+			if auth, ok := request["auth"]; ok {
+				encoded, _ := json.Marshal(auth)
+				parts = append(parts, string(encoded))
+			}
+		}
+	}
+	return strings.Join(append([]string{strings.ToUpper(method) + " " + rawUrl}, parts...), "\n")
+}
+
+// bodySignature renders a body the same way whether it was generated or
+// decoded from a collection file.
+func bodySignature(body map[string]interface{}) string {
+	mode, _ := body["mode"].(string)
+	if mode == "" || mode == "raw" {
+		raw, _ := body["raw"].(string)
+		return raw
+	}
+	encoded, _ := json.Marshal(body[mode])
+	return mode + " " + string(encoded)
+}
+
+// loadBaseSignatures reads a previously generated Postman collection and
+// returns the signatures of every request in it, including nested folders.
+func loadBaseSignatures(path string, by string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var base struct {
+		Items []interface{} `json:"item"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+	signatures := map[string]bool{}
+	forEachRequest(base.Items, func(item map[string]interface{}) {
+		signatures[requestSignature(item, by)] = true
+	})
+	return signatures, nil
+}
+
+// filterChangedItems drops every request already present in the base
+// collection, along with any folder left empty as a result.
+func filterChangedItems(items []interface{}, base map[string]bool, by string) []interface{} {
+	return filterItems(items, func(item map[string]interface{}) bool {
+		return !base[requestSignature(item, by)]
+	})
+}
+
+// dedupeItems keeps only the first request with each signature.
+func dedupeItems(items []interface{}, by string) []interface{} {
+	seen := map[string]bool{}
+	return filterItems(items, func(item map[string]interface{}) bool {
+		signature := requestSignature(item, by)
+		if seen[signature] {
+			return false
+		}
+		seen[signature] = true
+		return true
+	})
+}
+
+// filterItems keeps the requests for which keep returns true, in order,
+// dropping any folder left empty.
+func filterItems(items []interface{}, keep func(item map[string]interface{}) bool) []interface{} {
+	kept := []interface{}{}
+	for _, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if children, ok := item["item"].([]interface{}); ok {
+			children = filterItems(children, keep)
+			if len(children) == 0 {
+				continue
+			}
+			folder := map[string]interface{}{}
+			for key, value := range item {
+				folder[key] = value
+			}
+			folder["item"] = children
+			kept = append(kept, folder)
+			continue
+		}
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// collapseTestSets merges test-set folders whose requests have identical
+// signatures into the first of them, noting the merged sets in its description.
+func collapseTestSets(items []interface{}, by string) []interface{} {
+	collapsed := []interface{}{}
+	seen := map[string]map[string]interface{}{}
+	for _, v := range items {
+		folder, ok := v.(map[string]interface{})
+		children, isFolder := folder["item"].([]interface{})
+		if !ok || !isFolder {
+			collapsed = append(collapsed, v)
+			continue
+		}
+		signatures := []string{}
+		forEachRequest(children, func(item map[string]interface{}) {
+			signatures = append(signatures, requestSignature(item, by))
+		})
+		key := strings.Join(signatures, "\x00")
+		first, duplicate := seen[key]
+		if !duplicate {
+			seen[key] = folder
+			collapsed = append(collapsed, folder)
+			continue
+		}
+		note := fmt.Sprintf("Also recorded as %v", folder["name"])
+		if existing, _ := first["description"].(string); existing != "" {
+			note = existing + "\n" + note
+		}
+		first["description"] = note
+	}
+	return collapsed
+}
+
+// Folder layouts accepted by -group-by.
+const (
+	groupByTestSet = "testset"
+	groupByHost    = "host"
+)
+
+// groupItemsByHost regroups every request into one folder per host (with its
+// port, so services sharing localhost stay apart), in order of first use.
+func groupItemsByHost(items []interface{}) []interface{} {
+	grouped := []interface{}{}
+	folders := map[string]map[string]interface{}{}
+	forEachRequest(items, func(item map[string]interface{}) {
+		host := "other"
+		if request, ok := item["request"].(map[string]interface{}); ok {
+			if u, err := requestURL(request); err == nil && u.Host != "" {
+				host = u.Host
+			}
+		}
+		folder, ok := folders[host]
+		if !ok {
+			folder = map[string]interface{}{"name": host, "item": []interface{}{}}
+			folders[host] = folder
+			grouped = append(grouped, folder)
+		}
+		folder["item"] = append(folder["item"].([]interface{}), item)
+	})
+	return grouped
+}
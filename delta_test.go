@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFilterChangedItemsKeepsOnlyNewOrChangedRequests(t *testing.T) {
+	unchanged := parseTestCurl(t, `curl --url http://api/users`)
+	base := PostmanCollection{Items: []interface{}{
+		map[string]interface{}{"name": "test-set-0", "item": []interface{}{
+			unchanged,
+			parseTestCurl(t, `curl --url http://api/orders --data '{"id":1}'`),
+		}},
+	}}
+	data, err := json.Marshal(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basePath := filepath.Join(t.TempDir(), "base.json")
+	if err := os.WriteFile(basePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := loadBaseSignatures(basePath, signatureBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []interface{}{
+		map[string]interface{}{"name": "test-set-0", "item": []interface{}{
+			parseTestCurl(t, `curl --url http://api/users`),
+			parseTestCurl(t, `curl --url http://api/orders --data '{"id":2}'`),
+		}},
+		map[string]interface{}{"name": "test-set-1", "item": []interface{}{
+			parseTestCurl(t, `curl --url http://api/users`),
+		}},
+		map[string]interface{}{"name": "test-set-2", "item": []interface{}{
+			parseTestCurl(t, `curl --request DELETE --url http://api/users/1`),
+		}},
+	}
+	delta := filterChangedItems(items, signatures, signatureBody)
+
+	got := []string{}
+	for _, v := range delta {
+		folder := v.(map[string]interface{})
+		forEachRequest(folder["item"].([]interface{}), func(item map[string]interface{}) {
+			got = append(got, folder["name"].(string)+" "+requestSignature(item, signatureURL))
+		})
+	}
+	want := []string{"test-set-0 POST http://api/orders", "test-set-2 DELETE http://api/users/1"}
+	if len(got) != len(want) {
+		t.Fatalf("delta = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("delta[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCollapseTestSetsMergesIdenticalSets(t *testing.T) {
+	folder := func(name string, curls ...string) map[string]interface{} {
+		items := []interface{}{}
+		for _, curl := range curls {
+			items = append(items, parseTestCurl(t, curl))
+		}
+		return map[string]interface{}{"name": name, "item": items}
+	}
+	items := []interface{}{
+		folder("test-set-0", `curl http://api/users`, `curl http://api/orders -d 'a=1'`),
+		folder("test-set-1", `curl http://api/users`, `curl http://api/orders -d 'a=1'`),
+		folder("test-set-2", `curl http://api/users`),
+	}
+	collapsed := collapseTestSets(items, signatureBody)
+	if len(collapsed) != 2 {
+		t.Fatalf("got %d folders, want 2", len(collapsed))
+	}
+	first := collapsed[0].(map[string]interface{})
+	if first["name"] != "test-set-0" || first["description"] != "Also recorded as test-set-1" {
+		t.Errorf("merged folder = %v (%v), want test-set-0 noting test-set-1", first["name"], first["description"])
+	}
+	if second := collapsed[1].(map[string]interface{}); second["name"] != "test-set-2" {
+		t.Errorf("second folder = %v, want test-set-2", second["name"])
+	}
+}
+
+func TestDedupeItemsBySignature(t *testing.T) {
+	curls := []string{
+		`curl --url http://api/users --data '{"id":1}'`,
+		`curl --url http://api/users --data '{"id":2}'`,
+		`curl --url http://api/users --data '{"id":2}' --header 'X-Trace: 1'`,
+		`curl --url http://api/users --data '{"id":2}' --header 'X-Trace: 1'`,
+	}
+	tests := map[string]int{signatureURL: 1, signatureBody: 2, signatureFull: 3}
+	for by, want := range tests {
+		items := []interface{}{}
+		for _, curl := range curls {
+			items = append(items, parseTestCurl(t, curl))
+		}
+		if got := len(dedupeItems(items, by)); got != want {
+			t.Errorf("-dedupe-by %s kept %d requests, want %d", by, got, want)
+		}
+	}
+}
+
+func TestGroupItemsByHost(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://localhost:8080/users"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://localhost:9090/orders"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://localhost:8080/carts"),
+		"test-set-1/tests/test-2.yaml": keployTest("curl https://api.example.com/v1/health"),
+	}
+	opts := testOptions()
+	opts.groupBy = groupByHost
+	got := strings.Join(itemNames(generateTestCollection(t, fsys, opts).Items, ""), " ")
+	if want := "localhost:8080/users localhost:8080/carts localhost:9090/orders api.example.com/v1-health"; got != want {
+		t.Errorf("items = %s, want %s", got, want)
+	}
+}
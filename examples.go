@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// statusReason returns the reason phrase for a status code, falling back to
+// the recorded message for codes without a standard one.
+func statusReason(status int, statusMessage string) string {
+	if reason := http.StatusText(status); reason != "" {
+		return reason
+	}
+	return strings.TrimSpace(statusMessage)
+}
+
+// exampleName names a saved response after its status, e.g. "404 Not Found".
+func exampleName(status int, reason string) string {
+	if reason == "" {
+		return fmt.Sprint(status)
+	}
+	return fmt.Sprintf("%d %s", status, reason)
+}
+
+// isExampleOnly reports whether a test is tagged with
+// spec.metadata.example_only, marking an interaction recorded for
+// documentation that should not be sent when the collection runs.
+func isExampleOnly(yamlData map[string]interface{}) bool {
+	exampleOnly, _ := strconv.ParseBool(yamlString(yamlData, "spec.metadata.example_only"))
+	return exampleOnly
+}
+
+// markExampleOnly makes a request skip itself when run, leaving it in the
+// collection only to carry its saved example.
+func markExampleOnly(item map[string]interface{}) {
+	addScript(item, "prerequest", []string{"pm.execution.skipRequest();"})
+	appendDescription(item, "Example only: this request documents a recorded response and is skipped when the collection runs.")
+}
+
+// recordedExample builds a Postman saved response from the response keploy
+// recorded for a test case, or returns nil when there is none. With docs set
+// the example is also laid out for Postman's documentation view.
+func recordedExample(item, yamlData map[string]interface{}, docs bool) map[string]interface{} {
+	status, ok := yamlInt(yamlData, "spec.resp.status_code")
+	if !ok {
+		return nil
+	}
+	recordedHeaders := yamlStringMap(yamlData, "spec.resp.header")
+	headers := []map[string]string{}
+	for _, key := range sortedKeys(recordedHeaders) {
+		headers = append(headers, map[string]string{"key": key, "value": recordedHeaders[key]})
+	}
+	reason := statusReason(status, yamlString(yamlData, "spec.resp.status_message"))
+	example := map[string]interface{}{
+		"name":   exampleName(status, reason),
+		"status": reason,
+		"code":   status,
+		"header": headers,
+		"body":   yamlString(yamlData, "spec.resp.body"),
+	}
+	if docs {
+		documentExample(item, example, status, reason)
+	}
+	return example
+}
+
+// documentExample fills in the fields Postman's documentation view relies on:
+// the request that produced the example, the language used to render its
+// body, and a description on the request itself.
+func documentExample(item map[string]interface{}, example map[string]interface{}, status int, reason string) {
+	request, ok := item["request"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	example["originalRequest"] = request
+
+	contentType := ""
+	for _, header := range example["header"].([]map[string]string) {
+		if strings.EqualFold(header["key"], "Content-Type") {
+			contentType = header["value"]
+		}
+	}
+	body, _ := example["body"].(string)
+	example["_postman_previewlanguage"] = bodyLanguage(contentType, body)
+
+	if description, _ := request["description"].(string); description == "" {
+		method, _ := request["method"].(string)
+		path := "/"
+		if parsedUrl, err := requestURL(request); err == nil && parsedUrl.Path != "" {
+			path = parsedUrl.Path
+		}
+		request["description"] = fmt.Sprintf("`%s %s` responds with `%s`.", method, path, exampleName(status, reason))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExamplesAreNamedFromTheirStatus(t *testing.T) {
+	response := func(status, message string) []string {
+		return []string{"spec:", "  resp:", "    status_code: " + status, "    status_message: " + message, "    body: '{}'"}
+	}
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users", response("200", "OK")...),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/missing", response("404", "")...),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/slow", response("499", "Client Closed Request")...),
+		"test-set-0/tests/test-4.yaml": keployTest("curl http://api/odd", response("599", "")...),
+	}
+	opts := testOptions()
+	opts.examples = true
+	names := []string{}
+	forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+		examples := item["response"].([]interface{})
+		if len(examples) != 1 {
+			t.Fatalf("%v has %d examples, want 1", item["name"], len(examples))
+		}
+		names = append(names, examples[0].(map[string]interface{})["name"].(string))
+	})
+	want := []string{"200 OK", "404 Not Found", "499 Client Closed Request", "599"}
+	for i := range want {
+		if i >= len(names) || names[i] != want[i] {
+			t.Fatalf("example names = %q, want %q", names, want)
+		}
+	}
+}
+
+func TestDocsLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl -X POST http://api/users -d '{\"name\":\"a\"}'",
+			"spec:", "  resp:", "    status_code: 201", "    header:", "      Content-Type: application/json", `    body: '{"id":1}'`),
+	}
+	opts := testOptions()
+	opts.docs = true
+	collection := generateTestCollection(t, fsys, opts)
+	if collection.Info.Description == "" {
+		t.Error("collection has no description")
+	}
+	folder := collection.Items[0].(map[string]interface{})
+	if folder["description"] != "Requests recorded by keploy in test-set-0." {
+		t.Errorf("folder description = %q", folder["description"])
+	}
+	item := folder["item"].([]interface{})[0].(map[string]interface{})
+	request := testRequest(item)
+	if got, want := request["description"], "`POST /users` responds with `201 Created`."; got != want {
+		t.Errorf("request description = %q, want %q", got, want)
+	}
+	example := item["response"].([]interface{})[0].(map[string]interface{})
+	if example["_postman_previewlanguage"] != "json" || example["body"] != `{"id":1}` {
+		t.Errorf("example = %v, want a json preview of the recorded body", example)
+	}
+	original, _ := example["originalRequest"].(map[string]interface{})
+	if original["method"] != "POST" || requestRawUrl(original) != "http://api/users" {
+		t.Errorf("originalRequest = %v, want the recorded request", original)
+	}
+}
+
+func TestExampleOnlyItem(t *testing.T) {
+	recording := func(exampleOnly string) []string {
+		return []string{"spec:", "  metadata:", "    example_only: " + exampleOnly, "  resp:", "    status_code: 200", `    body: '{"id":1}'`}
+	}
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users/1", recording(`"true"`)...),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/users/2", recording(`"false"`)...),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/users/3", "spec:", "  resp:", "    status_code: 200"),
+	}
+	items := map[string]map[string]interface{}{}
+	forEachRequest(generateTestCollection(t, fsys, testOptions()).Items, func(item map[string]interface{}) {
+		items[item["name"].(string)] = item
+	})
+
+	item := items["users-1"]
+	if item == nil {
+		t.Fatalf("no users-1 item in %v", items)
+	}
+	examples, _ := item["response"].([]interface{})
+	if len(examples) != 1 || examples[0].(map[string]interface{})["body"] != `{"id":1}` {
+		t.Errorf("example-only item responses = %v, want the recorded example without -examples", item["response"])
+	}
+	if got := testScript(item, "prerequest"); got != "pm.execution.skipRequest();" {
+		t.Errorf("pre-request script = %q, want the request skipped", got)
+	}
+	if description, _ := testRequest(item)["description"].(string); !strings.HasPrefix(description, "Example only:") {
+		t.Errorf("description = %q", description)
+	}
+
+	for _, name := range []string{"users-2", "users-3"} {
+		if examples, _ := items[name]["response"].([]interface{}); len(examples) != 0 || testScript(items[name], "prerequest") != "" {
+			t.Errorf("%s: got an example or a skip script without example_only", name)
+		}
+	}
+}
@@ -2,8 +2,4 @@ module github.com/shivamsouravjha/goPost.git
 
 go 1.21.5
 
-require (
-	go.uber.org/multierr v1.10.0 // indirect
-	go.uber.org/zap v1.27.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
-)
+require gopkg.in/yaml.v2 v2.4.0
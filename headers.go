@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requestHeaders returns the headers of a generated request item.
+func requestHeaders(item map[string]interface{}) []map[string]string {
+	request, _ := item["request"].(map[string]interface{})
+	headers, _ := request["header"].([]map[string]string)
+	return headers
+}
+
+func setRequestHeaders(item map[string]interface{}, headers []map[string]string) {
+	if request, ok := item["request"].(map[string]interface{}); ok {
+		request["header"] = headers
+	}
+}
+
+// hasHeader reports whether headers set key, ignoring case.
+func hasHeader(headers []map[string]string, key string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header["key"], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAcceptEncoding applies the -accept-encoding mode to a request.
+// Recorded values like "gzip, deflate, br" can make Postman show compressed
+// responses as binary, so they can be dropped or pinned to identity.
+func normalizeAcceptEncoding(item map[string]interface{}, mode string) {
+	if mode == "keep" {
+		return
+	}
+	headers := []map[string]string{}
+	for _, header := range requestHeaders(item) {
+		if strings.EqualFold(header["key"], "Accept-Encoding") {
+			if mode == "drop" {
+				continue
+			}
+			header["value"] = "identity"
+		}
+		headers = append(headers, header)
+	}
+	setRequestHeaders(item, headers)
+}
+
+// singleValueHeaders may not be sent as a comma-joined list, so
+// mergeDuplicateHeaders keeps each of their declarations as recorded.
+var singleValueHeaders = map[string]bool{
+	"authorization":  true,
+	"content-length": true,
+	"content-type":   true,
+	"host":           true,
+	"user-agent":     true,
+	"set-cookie":     true,
+}
+
+// mergeDuplicateHeaders combines repeated declarations of a header into the
+// first one, comma-joining list-valued headers like Accept and joining Cookie
+// headers with "; " as browsers do.
+func mergeDuplicateHeaders(item map[string]interface{}) {
+	headers := []map[string]string{}
+	first := map[string]map[string]string{}
+	for _, header := range requestHeaders(item) {
+		name := strings.ToLower(header["key"])
+		if singleValueHeaders[name] {
+			headers = append(headers, header)
+			continue
+		}
+		merged, ok := first[name]
+		if !ok {
+			first[name] = header
+			headers = append(headers, header)
+			continue
+		}
+		separator := ", "
+		if name == "cookie" {
+			separator = "; "
+		}
+		merged["value"] += separator + header["value"]
+	}
+	setRequestHeaders(item, headers)
+}
+
+// headerTemplates collects repeated -header-template "Name: value" flags.
+type headerTemplates []map[string]string
+
+func (t *headerTemplates) String() string {
+	parts := []string{}
+	for _, header := range *t {
+		parts = append(parts, header["key"]+": "+header["value"])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (t *headerTemplates) Set(value string) error {
+	key, headerValue, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	*t = append(*t, map[string]string{
+		"key":   strings.TrimSpace(key),
+		"value": strings.TrimSpace(headerValue),
+	})
+	return nil
+}
+
+// applyHeaderTemplates sets every templated header on the request, replacing
+// recorded headers of the same name. A templated Authorization header also
+// replaces any auth block lifted from the recording.
+func applyHeaderTemplates(item map[string]interface{}, templates headerTemplates) {
+	if len(templates) == 0 {
+		return
+	}
+	headers := []map[string]string{}
+	for _, header := range requestHeaders(item) {
+		replaced := false
+		for _, template := range templates {
+			replaced = replaced || strings.EqualFold(header["key"], template["key"])
+		}
+		if !replaced {
+			headers = append(headers, header)
+		}
+	}
+	request, _ := item["request"].(map[string]interface{})
+	for _, template := range templates {
+		headers = append(headers, map[string]string{"key": template["key"], "value": template["value"]})
+		if strings.EqualFold(template["key"], "Authorization") {
+			delete(request, "auth")
+		}
+	}
+	setRequestHeaders(item, headers)
+}
+
+// liftSessionCookie replaces the value of the named cookie in every request's
+// Cookie header with {{session}} so the session can be rotated in one place.
+// It returns the first recorded value, to seed the variable with.
+func liftSessionCookie(items []interface{}, name string) string {
+	session := ""
+	forEachRequest(items, func(item map[string]interface{}) {
+		headers := requestHeaders(item)
+		for _, header := range headers {
+			if !strings.EqualFold(header["key"], "Cookie") {
+				continue
+			}
+			cookies := strings.Split(header["value"], ";")
+			for i, cookie := range cookies {
+				key, value, ok := strings.Cut(strings.TrimSpace(cookie), "=")
+				if !ok || key != name {
+					continue
+				}
+				if session == "" {
+					session = value
+				}
+				cookies[i] = strings.Replace(cookie, key+"="+value, key+"={{session}}", 1)
+			}
+			header["value"] = strings.Join(cookies, ";")
+		}
+	})
+	return session
+}
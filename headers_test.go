@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// headerList renders a generated item's headers as "Key: value" lines.
+func headerList(item map[string]interface{}) string {
+	lines := []string{}
+	for _, header := range requestHeaders(item) {
+		lines = append(lines, header["key"]+": "+header["value"])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestNormalizeAcceptEncoding(t *testing.T) {
+	tests := map[string]string{
+		"keep":     "Accept: */*\nAccept-Encoding: gzip, deflate, br",
+		"drop":     "Accept: */*",
+		"identity": "Accept: */*\nAccept-Encoding: identity",
+	}
+	for mode, want := range tests {
+		item, err := parseCurlCommand(`curl http://api/users -H 'Accept: */*' -H 'Accept-Encoding: gzip, deflate, br'`, parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		normalizeAcceptEncoding(item, mode)
+		if got := headerList(item); got != want {
+			t.Errorf("%s: headers =\n%s\nwant\n%s", mode, got, want)
+		}
+	}
+}
+
+func TestApplyHeaderTemplates(t *testing.T) {
+	item, err := parseCurlCommand(`curl http://api/users -H 'authorization: Bearer recorded' -H 'Accept: */*'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var templates headerTemplates
+	for _, value := range []string{"Authorization: Bearer {{token}}", "X-Env: staging"} {
+		if err := templates.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	applyHeaderTemplates(item, templates)
+	if got, want := headerList(item), "Accept: */*\nAuthorization: Bearer {{token}}\nX-Env: staging"; got != want {
+		t.Errorf("headers =\n%s\nwant\n%s", got, want)
+	}
+	if auth, ok := item["request"].(map[string]interface{})["auth"]; ok {
+		t.Errorf("auth = %v, want the recorded auth block dropped", auth)
+	}
+	if err := templates.Set("no colon"); err == nil {
+		t.Error("Set accepted a template without a colon")
+	}
+}
+
+func TestSessionCookieBecomesAVariable(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest(`curl http://api/users -H 'Cookie: theme=dark; sid=abc123; lang=en'`),
+		"test-set-0/tests/test-2.yaml": keployTest(`curl http://api/orders -b 'sid=def456'`),
+		"test-set-0/tests/test-3.yaml": keployTest(`curl http://api/health -H 'Cookie: xsid=1'`),
+	}
+	opts := testOptions()
+	opts.sessionCookie = "sid"
+	collection := generateTestCollection(t, fsys, opts)
+	cookies := []string{}
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		headers, _ := testRequest(item)["header"].([]interface{})
+		for _, v := range headers {
+			header := v.(map[string]interface{})
+			if header["key"] == "Cookie" {
+				cookies = append(cookies, header["value"].(string))
+			}
+		}
+	})
+	if got, want := strings.Join(cookies, " | "), "theme=dark; sid={{session}}; lang=en | sid={{session}} | xsid=1"; got != want {
+		t.Errorf("cookies = %s, want %s", got, want)
+	}
+	seeded := false
+	for _, variable := range collection.Variables {
+		seeded = seeded || variable["key"] == "session" && variable["value"] == "abc123"
+	}
+	if !seeded {
+		t.Errorf("variables = %v, want session seeded with the first recorded value", collection.Variables)
+	}
+}
+
+func TestMergeDuplicateHeaders(t *testing.T) {
+	item, err := parseCurlCommand(`curl http://api/users -H 'Accept: application/json' -H 'X-Trace: 1' -H 'accept: text/plain' -H 'Cookie: a=1' -H 'Cookie: b=2' -H 'Content-Type: text/plain' -H 'Content-Type: application/json'`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeDuplicateHeaders(item)
+	want := "Accept: application/json, text/plain\nX-Trace: 1\nCookie: a=1; b=2\nContent-Type: text/plain\nContent-Type: application/json"
+	if got := headerList(item); got != want {
+		t.Errorf("headers =\n%s\nwant\n%s", got, want)
+	}
+}
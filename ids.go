@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var reUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID reports whether id is a canonically formatted UUID.
+func isUUID(id string) bool {
+	return reUUID.MatchString(id)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+func formatUUID(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// uuidURLNamespace is the RFC 4122 namespace for names that are URLs.
+var uuidURLNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// newUUID5 returns the name-based (version 5, SHA-1) UUID of name.
+func newUUID5(namespace [16]byte, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+// Item id schemes accepted by -id-scheme.
+const (
+	idSchemeUUID  = "uuid"
+	idSchemeUUID5 = "uuid5"
+	idSchemeSeq   = "seq"
+)
+
+// idGenerator hands out the id of each request item in collection order.
+// key identifies the request by its folder, name and signature.
+type idGenerator interface {
+	nextID(key string) string
+}
+
+func newIDGenerator(scheme string) idGenerator {
+	switch scheme {
+	case idSchemeUUID:
+		return randomIDs{}
+	case idSchemeUUID5:
+		return &nameBasedIDs{seen: map[string]int{}}
+	case idSchemeSeq:
+		return &sequentialIDs{}
+	}
+	return nil
+}
+
+// randomIDs gives every request a fresh random UUID on each run.
+type randomIDs struct{}
+
+func (randomIDs) nextID(string) string {
+	return newUUID()
+}
+
+// nameBasedIDs derives a UUID from the request itself so regenerating the
+// collection keeps ids stable. Repeated keys get an occurrence suffix to stay
+// unique.
+type nameBasedIDs struct {
+	seen map[string]int
+}
+
+func (g *nameBasedIDs) nextID(key string) string {
+	g.seen[key]++
+	if n := g.seen[key]; n > 1 {
+		key = fmt.Sprintf("%s#%d", key, n)
+	}
+	return newUUID5(uuidURLNamespace, key)
+}
+
+// sequentialIDs numbers requests 1, 2, 3, ... in collection order.
+type sequentialIDs struct {
+	last int
+}
+
+func (g *sequentialIDs) nextID(string) string {
+	g.last++
+	return strconv.Itoa(g.last)
+}
+
+// assignItemIds sets the id of every request in items, including those in
+// nested folders.
+func assignItemIds(items []interface{}, ids idGenerator, folder string) {
+	for _, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := item["name"].(string)
+		if children, ok := item["item"].([]interface{}); ok {
+			assignItemIds(children, ids, folder+"/"+name)
+			continue
+		}
+		item["id"] = ids.nextID(folder + "/" + name + "\n" + requestSignature(item, signatureFull))
+	}
+}
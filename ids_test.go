@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFixedCollectionId(t *testing.T) {
+	const id = "b8623e1b-6922-4ff3-801c-a95d480859bd"
+	opts := testOptions()
+	opts.collectionId = id
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	for run := 0; run < 2; run++ {
+		if got := generateTestCollection(t, fsys, opts).Info.PostmanID; got != id {
+			t.Errorf("run %d: _postman_id = %q, want %q", run, got, id)
+		}
+	}
+}
+
+func TestCollectionIdMustBeAUUID(t *testing.T) {
+	for _, id := range []string{"b8623e1b-6922-4ff3-801c-a95d480859bd", "B8623E1B-6922-4FF3-801C-A95D480859BD"} {
+		if !isUUID(id) {
+			t.Errorf("isUUID(%q) = false, want true", id)
+		}
+	}
+	for _, id := range []string{"", "not-a-uuid", "b8623e1b69224ff3801ca95d480859bd", "{b8623e1b-6922-4ff3-801c-a95d480859bd}", "b8623e1b-6922-4ff3-801c-a95d480859bz"} {
+		if isUUID(id) {
+			t.Errorf("isUUID(%q) = true, want false", id)
+		}
+	}
+
+	out, code := runMain(t, t.TempDir(), "-collection-id", "not-a-uuid")
+	if code != 2 || !strings.Contains(out, "-collection-id must be a UUID") {
+		t.Errorf("exit %d, output %q; want exit 2 rejecting the id", code, out)
+	}
+}
+
+func TestNewUUID5MatchesRFC4122(t *testing.T) {
+	if got, want := newUUID5(uuidURLNamespace, "https://example.com/"), "dd2c1780-811a-5296-81c5-178a0ef488bc"; got != want {
+		t.Errorf("newUUID5 = %s, want %s", got, want)
+	}
+}
+
+func TestIdSchemes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/orders"),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/users"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api/users"),
+	}
+	ids := func(scheme string) []string {
+		opts := testOptions()
+		opts.idScheme = scheme
+		got := []string{}
+		forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+			id, _ := item["id"].(string)
+			got = append(got, id)
+		})
+		return got
+	}
+	distinct := func(scheme string, got []string) {
+		seen := map[string]bool{}
+		for _, id := range got {
+			if seen[id] {
+				t.Errorf("%s: id %s is used twice in %v", scheme, id, got)
+			}
+			seen[id] = true
+		}
+	}
+
+	random, again := ids(idSchemeUUID), ids(idSchemeUUID)
+	distinct(idSchemeUUID, random)
+	for i, id := range random {
+		if !isUUID(id) || id[14] != '4' {
+			t.Errorf("uuid: id %q is not a random UUID", id)
+		}
+		if id == again[i] {
+			t.Errorf("uuid: id %s repeated across runs", id)
+		}
+	}
+
+	named := ids(idSchemeUUID5)
+	distinct(idSchemeUUID5, named)
+	for _, id := range named {
+		if !isUUID(id) || id[14] != '5' {
+			t.Errorf("uuid5: id %q is not a name-based UUID", id)
+		}
+	}
+	if again := ids(idSchemeUUID5); strings.Join(again, " ") != strings.Join(named, " ") {
+		t.Errorf("uuid5: ids changed across runs: %v, then %v", named, again)
+	}
+
+	if got := strings.Join(ids(idSchemeSeq), " "); got != "1 2 3 4" {
+		t.Errorf("seq: ids = %s, want 1 2 3 4", got)
+	}
+}
+
+func TestCollectionVersion(t *testing.T) {
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	opts := testOptions()
+	opts.output = t.TempDir() + "/output.json"
+	generateTestCollection(t, fsys, opts)
+	if data, err := os.ReadFile(opts.output); err != nil || strings.Contains(string(data), `"version"`) {
+		t.Errorf("without -collection-version the output has a version (err %v)", err)
+	}
+	opts.version = "1.4.0"
+	if got := generateTestCollection(t, fsys, opts).Info.Version; got != "1.4.0" {
+		t.Errorf("info.version = %q, want 1.4.0", got)
+	}
+}
+
+func TestExporterId(t *testing.T) {
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	opts := testOptions()
+	if got := generateTestCollection(t, fsys, opts).Info.ExporterID; got != "132182772" {
+		t.Errorf("default _exporter_id = %q", got)
+	}
+	opts.exporterId = "42"
+	if got := generateTestCollection(t, fsys, opts).Info.ExporterID; got != "42" {
+		t.Errorf("_exporter_id = %q, want 42", got)
+	}
+	for _, id := range []string{"", "abc", "12a"} {
+		if out, code := runMain(t, t.TempDir(), "-exporter-id="+id); code != 2 || !strings.Contains(out, "-exporter-id must be numeric") {
+			t.Errorf("-exporter-id %q: exit %d, output %q; want exit 2", id, code, out)
+		}
+	}
+}
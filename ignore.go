@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ignoreFileName is read from the root of the keploy directory.
+const ignoreFileName = ".goPostignore"
+
+// ignoreList holds the glob patterns of a .goPostignore file.
+type ignoreList []string
+
+// loadIgnoreFile reads .goPostignore, one glob per line with # comments,
+// returning an empty list when the file does not exist.
+func loadIgnoreFile(fsys fs.FS) (ignoreList, error) {
+	data, err := fs.ReadFile(fsys, ignoreFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	patterns := ignoreList{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, strings.TrimSuffix(strings.TrimPrefix(line, "/"), "/"))
+	}
+	return patterns, scanner.Err()
+}
+
+// matches reports whether the slash-separated path, relative to the keploy
+// directory, is excluded. Patterns match either the whole path, one of its
+// parent directories, or its base name.
+func (l ignoreList) matches(name string) bool {
+	for _, pattern := range l {
+		for p := name; p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
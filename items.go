@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// forEachRequest calls fn for every request item, descending into folders.
+func forEachRequest(items []interface{}, fn func(item map[string]interface{})) {
+	for _, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok || item == nil {
+			continue
+		}
+		if children, ok := item["item"].([]interface{}); ok {
+			forEachRequest(children, fn)
+			continue
+		}
+		fn(item)
+	}
+}
+
+// requestRawUrl returns the raw URL of a request whether Postman stored it
+// as a plain string or as a structured url object.
+func requestRawUrl(request map[string]interface{}) string {
+	switch u := request["url"].(type) {
+	case string:
+		return u
+	case map[string]interface{}:
+		raw, _ := u["raw"].(string)
+		return raw
+	}
+	return ""
+}
+
+// requestURL parses the URL a request calls. url.raw is used when it names a
+// host; a raw URL kept verbatim by -preserve-raw-url may lack the scheme, so
+// otherwise the URL is rebuilt from the structured protocol, host, port, path
+// and query fields.
+func requestURL(request map[string]interface{}) (*url.URL, error) {
+	parsedUrl, err := url.Parse(requestRawUrl(request))
+	if err == nil && parsedUrl.Host != "" {
+		return parsedUrl, nil
+	}
+	urlBlock, ok := request["url"].(map[string]interface{})
+	host := strings.Join(urlStrings(urlBlock["host"]), ".")
+	if !ok || host == "" {
+		return parsedUrl, err
+	}
+	scheme, _ := urlBlock["protocol"].(string)
+	if scheme == "" {
+		scheme = "http"
+	}
+	if port, _ := urlBlock["port"].(string); port != "" {
+		host += ":" + port
+	}
+	rebuilt := scheme + "://" + host + "/" + strings.Join(urlStrings(urlBlock["path"]), "/")
+	pairs := []queryPair{}
+	switch params := urlBlock["query"].(type) {
+	case []map[string]string:
+		for _, param := range params {
+			pairs = append(pairs, queryPair{param["key"], param["value"]})
+		}
+	case []interface{}:
+		for _, v := range params {
+			param, _ := v.(map[string]interface{})
+			key, _ := param["key"].(string)
+			value, _ := param["value"].(string)
+			pairs = append(pairs, queryPair{key, value})
+		}
+	}
+	if len(pairs) > 0 {
+		rebuilt += "?" + joinQuery(pairs)
+	}
+	return url.Parse(rebuilt)
+}
+
+// urlStrings returns the segments of a url.host or url.path field, which is a
+// string slice when generated and a list of strings once decoded.
+func urlStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		segments := []string{}
+		for _, segment := range v {
+			if s, ok := segment.(string); ok {
+				segments = append(segments, s)
+			}
+		}
+		return segments
+	}
+	return nil
+}
+
+// postmanVariableEscapes undoes the escaping url.URL applies to the braces of
+// Postman {{variables}} in a path.
+var postmanVariableEscapes = strings.NewReplacer("%7B%7B", "{{", "%7D%7D", "}}")
+
+// rawUrlString renders u for url.raw, keeping {{variables}} and encoded
+// slashes as written.
+func rawUrlString(u *url.URL) string {
+	s := u.String()
+	if raw, escaped := recordedPath(u), u.EscapedPath(); raw != escaped {
+		s = strings.Replace(s, escaped, raw, 1)
+	}
+	return postmanVariableEscapes.Replace(s)
+}
+
+// recordedPath returns u's path as it was written, provided that is
+// escaped the way url.URL would escape it apart from the braces of
+// {{variables}}. url.URL itself gives up on the written form, and with it any
+// encoded slash, when the braces appear.
+func recordedPath(u *url.URL) string {
+	if u.RawPath != "" {
+		check := *u
+		check.RawPath = strings.NewReplacer("{", "%7B", "}", "%7D").Replace(u.RawPath)
+		if check.EscapedPath() == check.RawPath {
+			return u.RawPath
+		}
+	}
+	return u.EscapedPath()
+}
+
+// pathSegments splits u's path into Postman url.path segments. It splits the
+// escaped path, so an encoded slash as in /files/a%2Fb stays inside its
+// segment instead of starting a new one.
+func pathSegments(u *url.URL) []string {
+	escaped := postmanVariableEscapes.Replace(recordedPath(u))
+	return strings.Split(strings.TrimPrefix(escaped, "/"), "/")
+}
+
+// requestHeader returns the value of the named request header, or "" when it
+// is absent.
+func requestHeader(request map[string]interface{}, name string) string {
+	switch headers := request["header"].(type) {
+	case []map[string]string:
+		for _, header := range headers {
+			if strings.EqualFold(header["key"], name) {
+				return header["value"]
+			}
+		}
+	case []interface{}:
+		for _, v := range headers {
+			header, _ := v.(map[string]interface{})
+			if key, _ := header["key"].(string); strings.EqualFold(key, name) {
+				value, _ := header["value"].(string)
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// requestBody returns the body block of a request item, or nil when it has none.
+func requestBody(item map[string]interface{}) map[string]interface{} {
+	request, _ := item["request"].(map[string]interface{})
+	body, _ := request["body"].(map[string]interface{})
+	return body
+}
+
+// appendDescription adds a paragraph to a request's description.
+func appendDescription(item map[string]interface{}, text string) {
+	request, ok := item["request"].(map[string]interface{})
+	if !ok || text == "" {
+		return
+	}
+	if existing, _ := request["description"].(string); existing != "" {
+		text = existing + "\n\n" + text
+	}
+	request["description"] = text
+}
+
+// headerLines returns a request's enabled headers as sorted "name: value"
+// lines with lowercased names, whether generated or decoded from JSON.
+func headerLines(request map[string]interface{}) []string {
+	lines := []string{}
+	switch headers := request["header"].(type) {
+	case []map[string]string:
+		for _, header := range headers {
+			lines = append(lines, strings.ToLower(header["key"])+": "+header["value"])
+		}
+	case []interface{}:
+		for _, v := range headers {
+			header, _ := v.(map[string]interface{})
+			if disabled, _ := header["disabled"].(bool); disabled {
+				continue
+			}
+			key, _ := header["key"].(string)
+			value, _ := header["value"].(string)
+			lines = append(lines, strings.ToLower(key)+": "+value)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEncodedSlashStaysInItsSegment(t *testing.T) {
+	tests := []struct {
+		curl, raw, path, name string
+	}{
+		{`curl http://api/files/a%2Fb`, "http://api/files/a%2Fb", "[files a%2Fb]", "files-a/b"},
+		{`curl 'http://api/files/a%2fb/raw?x=1'`, "http://api/files/a%2fb/raw?x=1", "[files a%2fb raw]", "files-a/b-raw"},
+		{`curl http://api/{{bucket}}/a%2Fb`, "http://api/{{bucket}}/a%2Fb", "[{{bucket}} a%2Fb]", "{{bucket}}-a/b"},
+		{`curl http://api/files/a/b`, "http://api/files/a/b", "[files a b]", "files-a-b"},
+	}
+	for _, tt := range tests {
+		item, err := parseCurlCommand(tt.curl, parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := item["request"].(map[string]interface{})
+		if got := requestRawUrl(request); got != tt.raw {
+			t.Errorf("%s: raw = %s, want %s", tt.curl, got, tt.raw)
+		}
+		if got := fmt.Sprint(request["url"].(map[string]interface{})["path"]); got != tt.path {
+			t.Errorf("%s: path = %s, want %s", tt.curl, got, tt.path)
+		}
+		if item["name"] != tt.name {
+			t.Errorf("%s: name = %v, want %s", tt.curl, item["name"], tt.name)
+		}
+	}
+
+	item, err := parseCurlCommand(`curl http://api/files/a%2Fb`, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyPathPrefix(item, "/v2")
+	urlBlock := item["request"].(map[string]interface{})["url"].(map[string]interface{})
+	if urlBlock["raw"] != "http://api/v2/files/a%2Fb" || fmt.Sprint(urlBlock["path"]) != "[v2 files a%2Fb]" {
+		t.Errorf("after -prefix-path: raw = %v, path = %v", urlBlock["raw"], urlBlock["path"])
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestK6Script(t *testing.T) {
+	collection := testCollection(t,
+		`curl http://api/users -H 'Accept: application/json' -H 'Accept: text/plain' -H 'Cookie: a=1' -H 'Cookie: b=2'`,
+		`curl http://api/avatars -H 'Content-Type: multipart/form-data; boundary=recorded' -F name=a -F 'avatar=@photos/me.png;type=image/png'`,
+	)
+	script := buildK6Script(collection)
+	for _, want := range []string{
+		"import { FormData } from 'https://jslib.k6.io/formdata/0.0.2/index.js';",
+		`const file1 = open("photos/me.png", 'b');`,
+		`http.request("GET", "http://api/users", null, { headers: { "Accept": "application/json, text/plain", "Cookie": "a=1; b=2" } });`,
+		`const form1 = new FormData();`,
+		`form1.append("name", "a");`,
+		`form1.append("avatar", http.file(file1, "me.png", "image/png"));`,
+		`http.request("POST", "http://api/avatars", form1.body(), { headers: { "Content-Type": "multipart/form-data; boundary=" + form1.boundary } });`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script is missing %s:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "recorded") {
+		t.Errorf("script kept the recorded multipart boundary:\n%s", script)
+	}
+}
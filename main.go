@@ -1,70 +1,310 @@
 package main
 
 import (
+	"archive/zip"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/fs"
+	"mime"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
-
-	"go.uber.org/zap"
-	"gopkg.in/yaml.v2"
+	"time"
 )
 
-func parseCurlCommand(curlCommand string) map[string]interface{} {
-	// Normalize the curl command by removing newlines and backslashes for easier processing
-	curlCommand = strings.Replace(curlCommand, "\\\n", " ", -1)
-	curlCommand = strings.Replace(curlCommand, "\n", " ", -1)
+// parseOptions carries what parseCurlCommand needs beyond the command itself.
+type parseOptions struct {
+	// defaultHost is used when the command only records a path and carries
+	// no Host header of its own.
+	defaultHost string
+	// stdinBody supplies the body of a --data @- command, which curl would
+	// have read from its standard input; it may be nil.
+	stdinBody func() string
+	// preserveRawUrl keeps url.raw exactly as the command wrote it instead
+	// of re-encoding it.
+	preserveRawUrl bool
+}
+
+// parseCurlCommand converts a curl command into a Postman request item.
+func parseCurlCommand(curlCommand string, popts parseOptions) (map[string]interface{}, error) {
+	args := parseCurlArgs(curlWords(curlCommand))
+	// A blank --request '' leaves the method to the fallback below
+	method, extractedUrl := strings.ToUpper(strings.TrimSpace(args.method)), args.url
+
+	// Extract headers; supported Authorization schemes become a Postman auth block
+	headers := []map[string]string{}
+	contentType, hostHeader := "", ""
+	var auth map[string]interface{}
+	chunked := false
+	for _, header := range args.headers {
+		key, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		// Postman frames the body itself, so the recorded chunked encoding
+		// and any Content-Length sent alongside it do not carry over
+		if strings.EqualFold(key, "Transfer-Encoding") && strings.Contains(strings.ToLower(value), "chunked") {
+			chunked = true
+			continue
+		}
+		if strings.EqualFold(key, "Authorization") {
+			if headerAuth := authFromHeader(value); headerAuth != nil {
+				auth = headerAuth
+				continue
+			}
+		}
+		headers = append(headers, map[string]string{
+			"key":   key,
+			"value": value,
+		})
+		switch {
+		case strings.EqualFold(key, "Content-Type"):
+			contentType = strings.ToLower(value)
+		case strings.EqualFold(key, "Host"):
+			hostHeader = value
+		}
+	}
 
-	// Regular expressions to capture parts of the curl command
-	reMethodAndUrl := regexp.MustCompile(`--request\s+(\w+)\s+--url\s+([^ ]+)`)
-	reHeader := regexp.MustCompile(`--header '([^:]+): ([^']*)'`)
-	reData := regexp.MustCompile(`--data '(\{.*?\})'`)
-	reDataRaw := regexp.MustCompile(`--data-raw '(\{.*?\})'`)
+	if len(args.cookies) > 0 {
+		headers = append(headers, map[string]string{
+			"key":   "Cookie",
+			"value": strings.Join(args.cookies, "; "),
+		})
+	}
 
-	// Extract method and URL
-	matches := reMethodAndUrl.FindStringSubmatch(curlCommand)
-	method, extractedUrl := "GET", ""
-	if len(matches) > 2 {
-		method = matches[1]
-		extractedUrl = matches[2]
+	if chunked {
+		unframed := []map[string]string{}
+		for _, header := range headers {
+			if !strings.EqualFold(header["key"], "Content-Length") {
+				unframed = append(unframed, header)
+			}
+		}
+		headers = unframed
 	}
+
+	// --json sets both headers unless the command sets them itself
+	if args.jsonFlags > 0 {
+		for _, key := range []string{"Content-Type", "Accept"} {
+			if !hasHeader(headers, key) {
+				headers = append(headers, map[string]string{"key": key, "value": "application/json"})
+			}
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+	}
+
+	// An explicit Authorization header replaces the one curl builds from -u
+	if args.user != "" && auth == nil && !hasHeader(headers, "Authorization") {
+		username, password, _ := strings.Cut(args.user, ":")
+		auth = basicAuth(username, password)
+	}
+	if args.bearer != "" {
+		auth = bearerAuth(args.bearer)
+	}
+
+	// Some recordings keep only the path, with the host in a header or in
+	// the test case metadata
+	if strings.HasPrefix(extractedUrl, "/") {
+		if hostHeader != "" {
+			extractedUrl = hostHeader + extractedUrl
+		} else if popts.defaultHost != "" {
+			extractedUrl = popts.defaultHost + extractedUrl
+		}
+	}
+
+	if extractedUrl == "" {
+		return nil, errors.New("no URL found in curl command")
+	}
+	// A bare path left unresolved would otherwise become http:///path
+	if strings.HasPrefix(extractedUrl, "/") {
+		return nil, fmt.Errorf("URL %q has no host and no Host header or host metadata to resolve it against", extractedUrl)
+	}
+
+	recordedUrl := extractedUrl
 	// Default to http if no scheme is specified
 	if !strings.Contains(extractedUrl, "://") {
 		extractedUrl = "http://" + extractedUrl
 	}
 	parsedUrl, err := url.Parse(extractedUrl)
-	if err != nil || parsedUrl.Hostname() == "" {
-		fmt.Println("Error parsing URL or invalid URL provided:", err)
-		return nil
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+	if parsedUrl.Hostname() == "" {
+		return nil, fmt.Errorf("URL %q has no host", extractedUrl)
 	}
+	// A quoted URL may hold literal spaces; url.URL encodes those in the
+	// path but keeps the query as written, so encode them there the way curl
+	// does before sending
+	parsedUrl.RawQuery = strings.ReplaceAll(parsedUrl.RawQuery, " ", "%20")
 
-	// Extract headers
-	headers := []map[string]string{}
-	for _, match := range reHeader.FindAllStringSubmatch(curlCommand, -1) {
-		headers = append(headers, map[string]string{
-			"key":   match[1],
-			"value": match[2],
-		})
+	// A Postman request has a single body, so options curl would splice into
+	// one invalid body, or refuses outright, are rejected
+	switch {
+	case args.jsonFlags > 0 && args.dataFlags > 0:
+		return nil, errors.New("--json cannot be combined with --data")
+	case len(args.forms) > 0 && args.dataFlags+args.jsonFlags > 0:
+		return nil, errors.New("--form cannot be combined with --data or --json")
+	case len(args.dataFiles) > 0 && (args.dataFlags+args.jsonFlags > 1 || args.get):
+		return nil, fmt.Errorf("the body read from @%s cannot be combined with other data or -G", args.dataFiles[0])
 	}
 
-	// Extract data
-	dataMatch := reData.FindStringSubmatch(curlCommand)
-	if len(dataMatch) == 0 {
-		dataMatch = reDataRaw.FindStringSubmatch(curlCommand)
+	// curl joins repeated --data values with &, the way a form is encoded
+	rawData := strings.Join(args.data, "&") + strings.Join(args.json, "")
+	readsStdin := rawData == "" && args.readsStdin
+	if readsStdin && popts.stdinBody != nil {
+		rawData = popts.stdinBody()
+	}
+	if chunked {
+		rawData = dechunkBody(rawData)
+	}
+	// -G sends the data as the query string of a GET instead of as a body
+	if args.get {
+		pairs := []string{}
+		if rawData != "" {
+			pairs = append(pairs, rawData)
+		}
+		for _, field := range args.urlencoded {
+			pairs = append(pairs, curlURLEncode(field))
+		}
+		if query := strings.Join(pairs, "&"); query != "" {
+			separator := "?"
+			if strings.Contains(recordedUrl, "?") && !strings.HasSuffix(recordedUrl, "?") {
+				separator = "&"
+			}
+			recordedUrl = strings.TrimSuffix(recordedUrl, "?") + separator + query
+			if parsedUrl.RawQuery != "" {
+				query = parsedUrl.RawQuery + "&" + query
+			}
+			parsedUrl.RawQuery = query
+		}
+		rawData, readsStdin, args.urlencoded = "", false, nil
+		if method == "" {
+			method = "GET"
+		}
+	}
+	body := map[string]interface{}{
+		"mode": "raw",
+		"raw":  rawData,
 	}
-	rawData := ""
-	if len(dataMatch) > 1 {
-		rawData = dataMatch[1]
+	if rawData != "" {
+		body["options"] = map[string]interface{}{
+			"raw": map[string]interface{}{"language": bodyLanguage(contentType, rawData)},
+		}
 	}
 
-	// Extract the last segment of the path as the name
-	pathSegments := strings.Split(strings.Trim(parsedUrl.Path, "/"), "/")
-	// Create the name by joining segments with dashes
-	name := strings.Join(pathSegments, "-")
+	// curl reads an @file body when it sends the request, and Postman can
+	// read the same file
+	if len(args.dataFiles) > 0 {
+		body = map[string]interface{}{
+			"mode": "file",
+			"file": map[string]interface{}{"src": args.dataFiles[0]},
+		}
+	}
+
+	// Form posts carry key/value pairs, either as repeated --data-urlencode
+	// flags or as an already encoded --data body
+	formEncoded := contentType == "" || strings.HasPrefix(contentType, "application/x-www-form-urlencoded")
+	if len(args.urlencoded) > 0 || (formEncoded && looksFormEncoded(rawData)) {
+		fields := []map[string]string{}
+		for _, pair := range splitQuery(rawData) {
+			key, _ := url.QueryUnescape(pair.key)
+			value, _ := url.QueryUnescape(pair.value)
+			fields = append(fields, map[string]string{"key": key, "value": value})
+		}
+		for _, field := range args.urlencoded {
+			// curl encodes the part after the first = itself
+			key, value, _ := strings.Cut(field, "=")
+			fields = append(fields, map[string]string{"key": key, "value": value})
+		}
+		body = map[string]interface{}{
+			"mode":       "urlencoded",
+			"urlencoded": fields,
+		}
+	}
+
+	// Multipart requests carry their fields as repeated --form flags
+	if len(args.forms) > 0 || strings.HasPrefix(contentType, "multipart/form-data") {
+		formData := []map[string]string{}
+		for _, form := range args.forms {
+			if form.literal {
+				formData = append(formData, parseFormString(form.value))
+			} else {
+				formData = append(formData, parseFormField(form.value))
+			}
+		}
+		body = map[string]interface{}{
+			"mode":     "formdata",
+			"formdata": formData,
+		}
+	}
+
+	// Without an explicit --request curl sends POST whenever there is a body
+	if method == "" {
+		method = "GET"
+		if args.head {
+			method = "HEAD"
+		} else if rawData != "" || readsStdin || len(args.dataFiles) > 0 || len(args.urlencoded) > 0 || len(args.forms) > 0 {
+			method = "POST"
+		}
+	}
+
+	// --resolve only pins DNS for the recording, so keep it as a note on the
+	// request instead of changing the URL Postman will call
+	notes := []string{}
+	for _, resolve := range args.resolves {
+		notes = append(notes, "Recorded with curl --resolve "+resolve)
+	}
+
+	// Name the request after its path, joining the decoded segments with
+	// dashes
+	segments := pathSegments(parsedUrl)
+	names := []string{}
+	for _, segment := range segments {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+		if segment != "" {
+			names = append(names, segment)
+		}
+	}
+	name := strings.Join(names, "-")
+
+	rawUrl := rawUrlString(parsedUrl)
+	if popts.preserveRawUrl {
+		rawUrl = recordedUrl
+	}
+	request := map[string]interface{}{
+		"method": method,
+		"header": headers,
+		"body":   body,
+		"url": map[string]interface{}{
+			"raw":      rawUrl,
+			"protocol": parsedUrl.Scheme,
+			"host":     []string{parsedUrl.Hostname()},
+			"port":     parsedUrl.Port(),
+			"path":     segments,
+			"query":    queryParams(parsedUrl.RawQuery),
+		},
+	}
+	if auth != nil {
+		request["auth"] = auth
+	}
+	if args.proxy != "" {
+		if proxy := proxyConfig(args.proxy); proxy != nil {
+			request["proxy"] = proxy
+		}
+	}
+	if len(notes) > 0 {
+		request["description"] = strings.Join(notes, "\n")
+	}
 
 	// Constructing the response
 	return map[string]interface{}{
@@ -72,140 +312,1037 @@ func parseCurlCommand(curlCommand string) map[string]interface{} {
 		"protocolProfileBehavior": map[string]interface{}{
 			"disableBodyPruning": true,
 		},
-		"request": map[string]interface{}{
-			"method": method,
-			"header": headers,
-			"body": map[string]interface{}{
-				"mode": "raw",
-				"raw":  rawData,
-			},
-			"url": map[string]interface{}{
-				"raw":      parsedUrl.String(),
-				"protocol": parsedUrl.Scheme,
-				"host":     []string{parsedUrl.Hostname()},
-				"port":     parsedUrl.Port(),
-				"path":     []string{strings.TrimLeft(parsedUrl.Path, "/")},
-				"query":    parsedUrl.Query(),
-			},
-		},
+		"request":  request,
 		"response": []interface{}{},
+	}, nil
+}
+
+// proxyConfig converts a curl --proxy value such as http://proxy:3128 into a
+// Postman request proxy, applied to every URL the request may call.
+func proxyConfig(proxy string) map[string]interface{} {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	parsedProxy, err := url.Parse(proxy)
+	if err != nil || parsedProxy.Hostname() == "" {
+		return nil
+	}
+	// curl falls back to port 1080 when the proxy does not name one
+	port, err := strconv.Atoi(parsedProxy.Port())
+	if err != nil {
+		port = 1080
+	}
+	return map[string]interface{}{
+		"match":    "http+https://*/*",
+		"host":     parsedProxy.Hostname(),
+		"port":     port,
+		"tunnel":   false,
+		"disabled": false,
 	}
 }
 
+// parseFormField converts a curl --form value (name=value[;type=mime]) into a
+// Postman formdata entry.
+func parseFormField(field string) map[string]string {
+	key, value, _ := strings.Cut(field, "=")
+	entry := map[string]string{"key": key}
+
+	// curl lets a field override its part content type with a ;type= suffix
+	if i := strings.LastIndex(value, ";type="); i >= 0 {
+		entry["contentType"] = value[i+len(";type="):]
+		value = value[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(value, "@"):
+		entry["type"] = "file"
+		entry["src"] = value[1:]
+	case looksLikeXML(value):
+		// A leading "<" normally tells curl to read the value from a file, but
+		// recorded XML payloads start with one too and must stay inline text
+		entry["type"] = "text"
+		entry["value"] = value
+		if entry["contentType"] == "" {
+			entry["contentType"] = "application/xml"
+		}
+	case strings.HasPrefix(value, "<"):
+		entry["type"] = "file"
+		entry["src"] = value[1:]
+	default:
+		entry["type"] = "text"
+		entry["value"] = value
+	}
+	return entry
+}
+
+// parseFormString converts a curl --form-string value into a Postman formdata
+// text entry. curl sends the value literally, so a leading @ or < and any
+// ;type= suffix are part of it.
+func parseFormString(field string) map[string]string {
+	key, value, _ := strings.Cut(field, "=")
+	return map[string]string{"key": key, "value": value, "type": "text"}
+}
+
+// curlWords splits a curl command into shell words, removing quotes and
+// backslash escapes the way the shell would before curl sees them. Besides
+// plain quotes it understands bash's $'...' quoting, which browsers use when
+// copying a request as cURL.
+func curlWords(curlCommand string) []string {
+	words := []string{}
+	var word strings.Builder
+	// quote is the quote character of the current quoted section, or $ for
+	// a $'...' one
+	var quote rune
+	inWord, escaped := false, false
+	runes := []rune(curlCommand)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '$' && r == '\'':
+			quote = 0
+		case quote == '$' && r == '\\':
+			decoded, consumed := ansiCEscape(runes[i+1:])
+			word.WriteString(decoded)
+			i += consumed
+		case quote == '$':
+			word.WriteRune(r)
+		case escaped && (r == '\n' || r == '\r'):
+			// A backslash at the end of a line continues the command on
+			// the next one; the line break itself is dropped (a CRLF ending
+			// leaves its \n to separate the words)
+			escaped = false
+		case escaped:
+			// Within double quotes a backslash only escapes the characters
+			// the shell treats specially there
+			escaped, inWord = false, true
+			if quote == '"' && !strings.ContainsRune("$`\"\\", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote == 0 && r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			quote, inWord = '$', true
+			i++
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote, inWord = r, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// ansiCEscapes are the single-character escapes of bash's $'...' quoting.
+var ansiCEscapes = map[rune]string{
+	'a': "\a", 'b': "\b", 'e': "\x1b", 'E': "\x1b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+	'\\': "\\", '\'': "'", '"': "\"", '?': "?",
+}
+
+// ansiCEscape decodes the escape sequence following a backslash inside
+// $'...', returning its value and how many runes of rest it used. As in
+// bash, \xHH and octal escapes give single bytes and unknown escapes are
+// kept as written.
+func ansiCEscape(rest []rune) (string, int) {
+	if len(rest) == 0 {
+		return "\\", 0
+	}
+	if decoded, ok := ansiCEscapes[rest[0]]; ok {
+		return decoded, 1
+	}
+	digits := func(from, max, base int) (int, int) {
+		n, value := 0, 0
+		for from+n < len(rest) && n < max {
+			digit, err := strconv.ParseUint(string(rest[from+n]), base, 8)
+			if err != nil {
+				break
+			}
+			value = value*base + int(digit)
+			n++
+		}
+		return value, n
+	}
+	switch rest[0] {
+	case 'x':
+		if value, n := digits(1, 2, 16); n > 0 {
+			return string([]byte{byte(value)}), 1 + n
+		}
+	case 'u', 'U':
+		max := 4
+		if rest[0] == 'U' {
+			max = 8
+		}
+		if value, n := digits(1, max, 16); n > 0 {
+			return string(rune(value)), 1 + n
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		value, n := digits(0, 3, 8)
+		return string([]byte{byte(value)}), n
+	}
+	return "\\" + string(rest[0]), 1
+}
+
+// curlValueFlags are the curl options that take a value, so it is not
+// mistaken for the URL or for another option.
+var curlValueFlags = map[string]bool{
+	// Request shape
+	"-X": true, "--request": true, "--url": true, "--url-query": true, "--request-target": true,
+	"-H": true, "--header": true, "-A": true, "--user-agent": true, "-e": true, "--referer": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true,
+	"--json": true, "-F": true, "--form": true, "--form-string": true, "-T": true, "--upload-file": true,
+	"-b": true, "--cookie": true, "-c": true, "--cookie-jar": true, "-r": true, "--range": true,
+	"-z": true, "--time-cond": true, "--etag-compare": true, "--etag-save": true, "--variable": true,
+	// Auth
+	"-u": true, "--user": true, "--oauth2-bearer": true, "--aws-sigv4": true, "--login-options": true,
+	"--sasl-authzid": true, "--delegation": true, "--krb": true, "--service-name": true, "--netrc-file": true,
+	// Connection
+	"--connect-to": true, "--resolve": true, "--interface": true, "--local-port": true, "--dns-servers": true,
+	"--dns-interface": true, "--dns-ipv4-addr": true, "--dns-ipv6-addr": true, "--doh-url": true,
+	"--unix-socket": true, "--abstract-unix-socket": true, "--ip-tos": true, "--vlan-priority": true,
+	"--proto": true, "--proto-default": true, "--proto-redir": true, "--alt-svc": true, "--hsts": true,
+	"--haproxy-clientip": true, "--ipfs-gateway": true,
+	// Proxies
+	"-x": true, "--proxy": true, "-U": true, "--proxy-user": true, "--proxy-header": true, "--preproxy": true,
+	"--noproxy": true, "--proxy1.0": true, "--socks4": true, "--socks4a": true, "--socks5": true,
+	"--socks5-hostname": true, "--socks5-gssapi-service": true, "--proxy-service-name": true,
+	"--proxy-cacert": true, "--proxy-capath": true, "--proxy-cert": true, "--proxy-cert-type": true,
+	"--proxy-key": true, "--proxy-key-type": true, "--proxy-pass": true, "--proxy-ciphers": true,
+	"--proxy-tls13-ciphers": true, "--proxy-crlfile": true, "--proxy-pinnedpubkey": true,
+	"--proxy-tlsauthtype": true, "--proxy-tlspassword": true, "--proxy-tlsuser": true,
+	// TLS
+	"-E": true, "--cert": true, "--cert-type": true, "--key": true, "--key-type": true, "--pass": true,
+	"--cacert": true, "--capath": true, "--crlfile": true, "--pinnedpubkey": true, "--ciphers": true,
+	"--tls13-ciphers": true, "--tls-max": true, "--curves": true, "--sigalgs": true, "--ech": true,
+	"--engine": true, "--random-file": true, "--egd-file": true, "--ssl-sessions": true,
+	"--tlsauthtype": true, "--tlspassword": true, "--tlsuser": true, "--hostpubmd5": true,
+	"--hostpubsha256": true, "--pubkey": true,
+	// Timing, retries and limits
+	"-m": true, "--max-time": true, "--connect-timeout": true, "--expect100-timeout": true,
+	"--happy-eyeballs-timeout-ms": true, "--keepalive-time": true, "--keepalive-cnt": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true, "--max-redirs": true,
+	"--max-filesize": true, "--limit-rate": true, "--rate": true, "-y": true, "--speed-time": true,
+	"-Y": true, "--speed-limit": true, "-C": true, "--continue-at": true, "--parallel-max": true,
+	// Output and tracing
+	"-o": true, "--output": true, "--output-dir": true, "--create-file-mode": true,
+	"-D": true, "--dump-header": true, "-w": true, "--write-out": true, "--stderr": true,
+	"--trace": true, "--trace-ascii": true, "--trace-config": true, "--libcurl": true, "-K": true, "--config": true,
+	// Other protocols
+	"-Q": true, "--quote": true, "-P": true, "--ftp-port": true, "--ftp-account": true,
+	"--ftp-alternative-to-user": true, "--ftp-method": true, "--ftp-ssl-ccc-mode": true,
+	"--mail-auth": true, "--mail-from": true, "--mail-rcpt": true, "-t": true, "--telnet-option": true,
+	"--tftp-blksize": true,
+}
+
+// curlArgs holds the parts of a curl command that shape the request, sorted
+// out of its words by parseCurlArgs.
+type curlArgs struct {
+	method string
+	url    string
+	// urlFromFlag records that url came from --url, which wins over a bare
+	// argument wherever either appears
+	urlFromFlag bool
+	headers     []string
+	// cookies holds -b name=value strings; a value without = names a cookie
+	// file, which cannot be followed
+	cookies []string
+	// data holds the --data bodies in order; @file references cannot be
+	// followed, so their paths are kept in dataFiles instead
+	data       []string
+	dataFiles  []string
+	readsStdin bool
+	urlencoded []string
+	// json holds the --json bodies, which curl concatenates as they are
+	json []string
+	// dataFlags and jsonFlags count the --data style and --json options,
+	// wherever their values come from
+	dataFlags int
+	jsonFlags int
+	// forms holds the --form and --form-string fields in order
+	forms  []curlForm
+	bearer string
+	// user is the -u user:password pair
+	user     string
+	proxy    string
+	resolves []string
+	// get records -G, which sends the data in the query string instead
+	get bool
+	// head records -I, which sends a HEAD request
+	head bool
+}
+
+// curlForm is one multipart field of a curl command; literal marks a
+// --form-string, whose value curl sends as written.
+type curlForm struct {
+	value   string
+	literal bool
+}
+
+// setSwitch records a curl option that takes no value but changes the
+// request, reporting whether option is one.
+func (args *curlArgs) setSwitch(option string) bool {
+	switch option {
+	case "-G", "--get":
+		args.get = true
+	case "-I", "--head":
+		args.head = true
+	default:
+		return false
+	}
+	return true
+}
+
+// curlURLEncode encodes a --data-urlencode value the way curl does: the part
+// after the first = (or the whole value without one) is percent-encoded and a
+// leading = is dropped.
+func curlURLEncode(field string) string {
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+	name, value, ok := strings.Cut(field, "=")
+	switch {
+	case !ok:
+		return escape(field)
+	case name == "":
+		return escape(value)
+	}
+	return name + "=" + escape(value)
+}
+
+// parseCurlArgs dispatches the words of a curl command to the options they
+// belong to. Option values may follow as the next word, after an equals sign
+// (--header=value) or, for short options, directly attached (-XPOST).
+// Options that do not affect the request are skipped along with their values.
+// The first bare argument is taken as the URL, unless a later one is a URL
+// with a scheme and host and the first is not.
+func parseCurlArgs(words []string) curlArgs {
+	args := curlArgs{}
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if i == 0 && word == "curl" {
+			continue
+		}
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			if args.url == "" || !args.urlFromFlag && !hasURLHost(args.url) && hasURLHost(word) {
+				args.url = word
+			}
+			continue
+		}
+		option, value, attached := word, "", false
+		if strings.HasPrefix(option, "--") {
+			if name, v, ok := strings.Cut(option, "="); ok {
+				option, value, attached = name, v, true
+			}
+		} else if len(option) > 2 {
+			// Short switches such as -s, -S or -v may be combined, and the
+			// last of them may take a value: -svXPOST or -svX POST
+			option = ""
+			for j := 1; j < len(word); j++ {
+				short := "-" + word[j:j+1]
+				if args.setSwitch(short) {
+					continue
+				}
+				if curlValueFlags[short] {
+					option = short
+					if j+1 < len(word) {
+						value, attached = word[j+1:], true
+					}
+					break
+				}
+			}
+		}
+		if args.setSwitch(option) {
+			continue
+		}
+		// Switches like -v, --verbose or --compressed do not change the request
+		if !curlValueFlags[option] {
+			continue
+		}
+		if !attached {
+			if i+1 == len(words) {
+				break
+			}
+			i++
+			value = words[i]
+		}
+
+		switch option {
+		case "-X", "--request":
+			args.method = value
+		case "--url":
+			if !args.urlFromFlag {
+				args.url, args.urlFromFlag = value, true
+			}
+		case "-H", "--header":
+			args.headers = append(args.headers, value)
+		case "-b", "--cookie":
+			if strings.Contains(value, "=") {
+				args.cookies = append(args.cookies, value)
+			}
+		case "-d", "--data", "--data-binary", "--data-ascii":
+			args.dataFlags++
+			switch {
+			case value == "@-":
+				args.readsStdin = true
+			case strings.HasPrefix(value, "@"):
+				args.dataFiles = append(args.dataFiles, value[1:])
+			default:
+				args.data = append(args.data, value)
+			}
+		case "--data-raw":
+			// --data-raw sends a leading @ as is
+			args.dataFlags++
+			args.data = append(args.data, value)
+		case "--json":
+			args.jsonFlags++
+			switch {
+			case value == "@-":
+				args.readsStdin = true
+			case strings.HasPrefix(value, "@"):
+				args.dataFiles = append(args.dataFiles, value[1:])
+			default:
+				args.json = append(args.json, value)
+			}
+		case "--data-urlencode":
+			args.dataFlags++
+			args.urlencoded = append(args.urlencoded, value)
+		case "-F", "--form":
+			args.forms = append(args.forms, curlForm{value: value})
+		case "--form-string":
+			args.forms = append(args.forms, curlForm{value: value, literal: true})
+		case "-u", "--user":
+			args.user = value
+		case "--oauth2-bearer":
+			args.bearer = value
+		case "-x", "--proxy":
+			args.proxy = value
+		case "--resolve":
+			args.resolves = append(args.resolves, value)
+		}
+	}
+	return args
+}
+
+// hasURLHost reports whether word is a URL with both a scheme and a host.
+func hasURLHost(word string) bool {
+	parsed, err := url.Parse(word)
+	return err == nil && strings.Contains(word, "://") && parsed.Host != ""
+}
+
+var reWindowsVar = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+
+// windowsVarsToPostman turns the %VAR% placeholders of curl commands recorded
+// on Windows into Postman {{VAR}} variables. Names of exactly two hex digits,
+// as in %E2%80, are left alone since they are more likely percent-encoding.
+func windowsVarsToPostman(curlCommand string) string {
+	return reWindowsVar.ReplaceAllStringFunc(curlCommand, func(match string) string {
+		name := match[1 : len(match)-1]
+		if len(name) == 2 && strings.Trim(name, "0123456789abcdefABCDEF") == "" {
+			return match
+		}
+		return "{{" + name + "}}"
+	})
+}
+
+// splitCurlComments separates the "# comment" lines written before or after a
+// curl command from the command itself.
+func splitCurlComments(curl string) (string, []string) {
+	lines := strings.Split(curl, "\n")
+	isComment := func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "#")
+	}
+	isSkippable := func(line string) bool {
+		return isComment(line) || strings.TrimSpace(line) == ""
+	}
+
+	start, end := 0, len(lines)
+	for start < end && isSkippable(lines[start]) {
+		start++
+	}
+	for end > start && isSkippable(lines[end-1]) {
+		end--
+	}
+
+	comments := []string{}
+	for i, line := range lines {
+		if (i < start || i >= end) && isComment(line) {
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")))
+		}
+	}
+	return strings.Join(lines[start:end], "\n"), comments
+}
+
+// looksLikeXML reports whether the value is an inline XML document or fragment.
+func looksLikeXML(value string) bool {
+	value = strings.TrimSpace(value)
+	return len(value) > 2 && strings.HasPrefix(value, "<") && strings.HasSuffix(value, ">")
+}
+
+type PostmanInfo struct {
+	PostmanID   string `json:"_postman_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Schema      string `json:"schema"`
+	ExporterID  string `json:"_exporter_id"`
+}
+
 type PostmanCollection struct {
-	Info struct {
-		PostmanID  string `json:"_postman_id"`
-		Name       string `json:"name"`
-		Schema     string `json:"schema"`
-		ExporterID string `json:"_exporter_id"`
-	} `json:"info"`
-	Items []interface{} `json:"item"`
+	Info      PostmanInfo            `json:"info"`
+	Items     []interface{}          `json:"item"`
+	Auth      map[string]interface{} `json:"auth,omitempty"`
+	Variables []map[string]string    `json:"variable,omitempty"`
+}
+
+// options holds the command line configuration for a run.
+type options struct {
+	baseCollection  string
+	format          string
+	minifyBodies    bool
+	collectionId    string
+	acceptEncoding  string
+	openAPISpec     string
+	strictCurl      bool
+	bundle          string
+	statusMapping   string
+	collapseSets    bool
+	nameFromSummary bool
+	curlComments    bool
+	examples        bool
+	docs            bool
+	headerTemplates headerTemplates
+	dedupe          bool
+	dedupeBy        string
+	uniqueNames     bool
+	latencyFactor   float64
+	pathPrefix      string
+	gzip            bool
+	validateSchema  bool
+	idScheme        string
+	queryFromBody   bool
+	sessionCookie   string
+	normalizeHosts  bool
+	version         string
+	seedVariables   bool
+	redactKeys      map[string]bool
+	output          string
+	name            string
+	curlFields      []string
+	maxDepth        int
+	preserveRawUrl  bool
+	report          string
+	windowsVars     bool
+	bodySchema      bool
+	latest          bool
+	exporterId      string
+	prettyBodies    string
+	mergeHeaders    bool
+	selfCheck       bool
+	groupBy         string
+	description     string
+	timestampScript bool
+	authEnv         string
+	sourcePath      bool
+	coverage        string
+	s3              string
+	splitBodies     bool
+	split           string
+	parallel        int
+	queryArrayStyle string
 }
 
 func main() {
-	cwd, err := os.Getwd()
-	if err != nil {
-		fmt.Println("Error:", err)
+	opts := options{}
+	flag.StringVar(&opts.baseCollection, "base", "", "only emit requests that are new or changed relative to this Postman collection")
+	flag.StringVar(&opts.format, "format", "postman", "output format: postman, openapi, csv or k6")
+	flag.StringVar(&opts.collectionId, "collection-id", "", "fixed _postman_id (a UUID) so re-imports update the same collection in Postman")
+	flag.StringVar(&opts.acceptEncoding, "accept-encoding", "keep", "how to handle recorded Accept-Encoding headers: keep, drop or identity")
+	flag.StringVar(&opts.openAPISpec, "openapi-spec", "", "name and describe requests from the matching operations in this OpenAPI spec")
+	flag.BoolVar(&opts.strictCurl, "strict-curl", false, "fail the run on the first curl command that cannot be parsed instead of skipping it")
+	flag.StringVar(&opts.bundle, "bundle", "", "also write a zip bundling the collection with a matching Postman environment")
+	flag.StringVar(&opts.statusMapping, "map-status-to-test", "", "generate status assertions, plus the extra header/schema checks mapped to each status in this file")
+	flag.BoolVar(&opts.collapseSets, "collapse-testsets", false, "merge test-sets whose requests are identical into one folder")
+	flag.BoolVar(&opts.nameFromSummary, "name-from-summary", false, "name requests after the test case's summary field when it has one")
+	flag.IntVar(&opts.parallel, "parallel", runtime.NumCPU(), "number of test-sets converted concurrently")
+	flag.StringVar(&opts.queryArrayStyle, "query-array-style", "", "rewrite list-valued query parameters as repeat (a=1&a=2) or brackets (a[]=1&a[]=2)")
+	flag.BoolVar(&opts.curlComments, "include-curl-comments", false, "copy # comment lines written around a curl command into the request description")
+	flag.BoolVar(&opts.examples, "examples", false, "attach each recorded response to its request as a saved example")
+	flag.BoolVar(&opts.docs, "docs", false, "lay the collection out for Postman's documentation view, with every request carrying its recorded example")
+	flag.Var(&opts.headerTemplates, "header-template", "add this \"Name: value\" header, e.g. \"Authorization: Bearer {{token}}\", to every request (repeatable)")
+	flag.BoolVar(&opts.dedupe, "dedupe", false, "drop requests that duplicate an earlier one")
+	flag.StringVar(&opts.dedupeBy, "dedupe-by", signatureBody, "what makes two requests duplicates for -dedupe, -base and -collapse-testsets: url (method+url), body (method+url+body) or full (also headers and auth)")
+	flag.BoolVar(&opts.uniqueNames, "fail-on-duplicate-names", false, "fail when two requests in the same folder end up with the same name")
+	flag.Float64Var(&opts.latencyFactor, "response-time-factor", 0, "assert each response arrives within its recorded latency multiplied by this factor (0 disables)")
+	flag.StringVar(&opts.pathPrefix, "prefix-path", "", "prepend a base path such as /v2 to every request URL")
+	flag.BoolVar(&opts.gzip, "gzip", false, "write the output gzip-compressed, e.g. output.json.gz")
+	flag.BoolVar(&opts.validateSchema, "schema-validate-output", false, "fail if the generated collection does not conform to the Postman v2.1.0 schema")
+	flag.StringVar(&opts.idScheme, "id-scheme", "", "give each request an id: uuid (random), uuid5 (derived from the request, stable across runs) or seq (1, 2, 3, ...)")
+	flag.BoolVar(&opts.queryFromBody, "query-from-body", false, "move form-encoded bodies of GET requests into the query string")
+	flag.StringVar(&opts.sessionCookie, "session-cookie", "", "replace this cookie's recorded value with a {{session}} collection variable")
+	flag.BoolVar(&opts.normalizeHosts, "normalize-hosts", false, "lowercase hostnames and drop default ports (:80 for http, :443 for https)")
+	flag.StringVar(&opts.version, "collection-version", "", "set the collection's info.version, e.g. 1.4.0, to track regenerations")
+	flag.BoolVar(&opts.seedVariables, "seed-path-variables", false, "back every :name path variable with a collection variable holding an example value")
+	flag.BoolVar(&opts.minifyBodies, "minify-bodies", false, "compact JSON request bodies")
+	archive := flag.String("archive", "", "read the keploy tests from this zip archive instead of the keploy directory")
+	remoteUrl := flag.String("url", "", "download a zip or tar archive of keploy tests from this URL and read them from it")
+	remoteTimeout := flag.Duration("url-timeout", 30*time.Second, "give up on the -url download after this long")
+	redactBodies := flag.String("redact-bodies", "", "comma-separated JSON body keys, e.g. password,ssn, whose values are masked")
+	input := flag.String("input", "keploy", "the keploy directory holding the test-sets")
+	flag.StringVar(&opts.output, "output", "", "write the result to this file (default output.json, or openapi.json, output.csv or script.js for the other formats)")
+	flag.StringVar(&opts.name, "name", "Atlantis", "the collection name")
+	curlFields := flag.String("curl-fields", defaultCurlFields, "comma-separated YAML paths tried in order for the curl command")
+	flag.IntVar(&opts.maxDepth, "max-depth", -1, "how many levels of subdirectories below each tests directory to read (-1 for no limit)")
+	flag.BoolVar(&opts.preserveRawUrl, "preserve-raw-url", false, "keep url.raw exactly as written in the curl command instead of re-encoding it")
+	flag.StringVar(&opts.report, "report", "", "also write a JSON summary of the run (counts, skipped tests with reasons, warnings) to this file")
+	flag.BoolVar(&opts.windowsVars, "windows-vars", false, "turn %VAR% placeholders in curl commands into Postman {{VAR}} variables")
+	flag.BoolVar(&opts.bodySchema, "body-schema", false, "document each JSON request body with a schema inferred from it in the request description")
+	flag.BoolVar(&opts.latest, "latest", false, "convert only the most recent test-set")
+	flag.StringVar(&opts.exporterId, "exporter-id", "132182772", "the numeric _exporter_id recorded in the collection")
+	flag.StringVar(&opts.prettyBodies, "pretty-bodies", "", "force the language of every raw body: json, text, xml, html or javascript")
+	flag.BoolVar(&opts.mergeHeaders, "merge-headers", false, "merge repeated list-valued headers such as Accept into one comma-joined header")
+	flag.BoolVar(&opts.selfCheck, "self-check", false, "warn about requests that do not survive a round trip through a generated curl command")
+	flag.StringVar(&opts.groupBy, "group-by", groupByTestSet, "folder layout: testset (one folder per test-set) or host (one folder per host)")
+	flag.BoolVar(&opts.timestampScript, "timestamp-script", false, "add a pre-request script setting {{timestamp}} to requests that use it")
+	flag.StringVar(&opts.authEnv, "collection-auth-from-env", "", "give the collection bearer auth using a {{token}} variable that defaults to this environment variable's value")
+	flag.BoolVar(&opts.sourcePath, "source-path", false, "note the test file each request was recorded in in its description")
+	flag.StringVar(&opts.coverage, "coverage", "", "also write the unique method and path combinations with their request counts to this file, or - for standard output")
+	flag.StringVar(&opts.s3, "s3", "", "also upload the output to this s3://bucket/key, with credentials from the AWS_* environment variables")
+	flag.BoolVar(&opts.splitBodies, "split-bodies-to-files", false, "write each raw body to its own file in a bodies directory next to the output and reference it from the request")
+	flag.StringVar(&opts.split, "split", "", "also write each top-level folder as its own collection in this directory, with a manifest.json index")
+	reverse := flag.String("reverse", "", "print the requests of this Postman collection as curl commands instead of generating one")
+	watch := flag.Bool("watch", false, "regenerate the collection whenever a test file in the keploy directory changes")
+	watchInterval := flag.Duration("watch-interval", time.Second, "how often -watch polls the keploy directory for changes")
+	flag.Parse()
+	// Without -name, name the collection after the project it is run in
+	nameSet := false
+	flag.Visit(func(f *flag.Flag) { nameSet = nameSet || f.Name == "name" })
+	projectName, projectDescription := projectMetadata(".")
+	if !nameSet && projectName != "" {
+		opts.name = projectName
+	}
+	opts.description = projectDescription
+	for _, field := range strings.Split(*curlFields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.curlFields = append(opts.curlFields, field)
+		}
+	}
+	if *redactBodies != "" {
+		opts.redactKeys = map[string]bool{}
+		for _, key := range strings.Split(*redactBodies, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				opts.redactKeys[strings.ToLower(key)] = true
+			}
+		}
+	}
+
+	if opts.format != "postman" && opts.format != "openapi" && opts.format != "csv" && opts.format != "k6" {
+		fmt.Println("Unknown output format:", opts.format)
+		os.Exit(2)
+	}
+	if opts.acceptEncoding != "keep" && opts.acceptEncoding != "drop" && opts.acceptEncoding != "identity" {
+		fmt.Println("Unknown -accept-encoding mode:", opts.acceptEncoding)
+		os.Exit(2)
+	}
+	if opts.queryArrayStyle != "" && opts.queryArrayStyle != "repeat" && opts.queryArrayStyle != "brackets" {
+		fmt.Println("Unknown -query-array-style:", opts.queryArrayStyle)
+		os.Exit(2)
+	}
+	if opts.dedupeBy != signatureURL && opts.dedupeBy != signatureBody && opts.dedupeBy != signatureFull {
+		fmt.Println("Unknown -dedupe-by signature:", opts.dedupeBy)
+		os.Exit(2)
+	}
+	if opts.idScheme != "" && newIDGenerator(opts.idScheme) == nil {
+		fmt.Println("Unknown -id-scheme:", opts.idScheme)
+		os.Exit(2)
+	}
+	if opts.collectionId != "" && !isUUID(opts.collectionId) {
+		fmt.Println("-collection-id must be a UUID, got:", opts.collectionId)
+		os.Exit(2)
+	}
+	if opts.groupBy != groupByTestSet && opts.groupBy != groupByHost {
+		fmt.Println("Unknown -group-by layout:", opts.groupBy)
+		os.Exit(2)
+	}
+	if opts.prettyBodies != "" && !bodyLanguages[opts.prettyBodies] {
+		fmt.Println("Unknown -pretty-bodies language:", opts.prettyBodies)
+		os.Exit(2)
+	}
+	// Each input mode reads the tests from a different place
+	if *remoteUrl != "" && (*watch || *archive != "") {
+		fmt.Println("-url cannot be combined with -watch or -archive")
+		os.Exit(2)
+	}
+	if *watch && *archive != "" {
+		fmt.Println("-watch cannot be combined with -archive")
+		os.Exit(2)
+	}
+	// These rewrite url.raw, which -preserve-raw-url promises to keep as written
+	if opts.preserveRawUrl {
+		conflicts := []struct {
+			flag string
+			set  bool
+		}{
+			{"-prefix-path", opts.pathPrefix != ""},
+			{"-normalize-hosts", opts.normalizeHosts},
+			{"-query-array-style", opts.queryArrayStyle != ""},
+			{"-query-from-body", opts.queryFromBody},
+		}
+		for _, conflict := range conflicts {
+			if conflict.set {
+				fmt.Println("-preserve-raw-url cannot be combined with", conflict.flag)
+				os.Exit(2)
+			}
+		}
+	}
+	if opts.split != "" && opts.format != "postman" {
+		fmt.Println("-split only applies to -format postman")
+		os.Exit(2)
+	}
+	if opts.s3 != "" {
+		if _, err := parseS3Location(opts.s3); err != nil {
+			fmt.Println("Invalid -s3 location:", err)
+			os.Exit(2)
+		}
+	}
+	if opts.exporterId == "" || strings.Trim(opts.exporterId, "0123456789") != "" {
+		fmt.Println("-exporter-id must be numeric, got:", opts.exporterId)
+		os.Exit(2)
+	}
+
+	if *reverse != "" {
+		curls, err := collectionToCurl(*reverse)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		fmt.Print(curls)
 		return
 	}
-	// Correctly format the directory path to include "keploy"
-	keployDir := filepath.Join(cwd, "keploy")
 
-	// Check if the directory exists
-	if _, err := os.Stat(keployDir); os.IsNotExist(err) {
-		fmt.Println("Keploy directory does not exist in the current working directory.")
+	if *archive != "" {
+		zr, err := zip.OpenReader(*archive)
+		if err != nil {
+			fmt.Println("Error opening archive:", err)
+			os.Exit(1)
+		}
+		defer zr.Close()
+		if err := generate(keployRoot(zr), opts); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
 		return
 	}
-	dir, err := ReadDir(keployDir, fs.FileMode(os.O_RDONLY))
-	if err != nil {
-		fmt.Println("creating a folder for the keploy generated testcases", zap.Error(err))
+
+	if *remoteUrl != "" {
+		fsys, cleanup, err := fetchArchive(*remoteUrl, *remoteTimeout)
+		if err != nil {
+			fmt.Println("Error downloading tests:", err)
+			os.Exit(1)
+		}
+		err = generate(keployRoot(fsys), opts)
+		cleanup()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	files, err := dir.ReadDir(0)
+	keployDir, err := filepath.Abs(*input)
 	if err != nil {
 		fmt.Println("Error:", err)
-		return
+		os.Exit(1)
 	}
+
+	// Check if the directory exists
+	if info, err := os.Stat(keployDir); err != nil || !info.IsDir() {
+		fmt.Printf("Keploy directory %s does not exist.\n", keployDir)
+		os.Exit(1)
+	}
+	if err := generate(os.DirFS(keployDir), opts); err != nil {
+		fmt.Println("Error:", err)
+		if !*watch {
+			os.Exit(1)
+		}
+	}
+
+	if *watch {
+		fmt.Println("Watching", keployDir, "for changes")
+		err := watchDir(keployDir, *watchInterval, watchDebounce, func() {
+			if err := generate(os.DirFS(keployDir), opts); err != nil {
+				fmt.Println("Error:", err)
+			}
+		}, nil)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+}
+
+// keployRoot returns the directory holding the test-sets within an archive,
+// which may either contain them at its root or inside a keploy folder.
+func keployRoot(fsys fs.FS) fs.FS {
+	if info, err := fs.Stat(fsys, "keploy"); err == nil && info.IsDir() {
+		if sub, err := fs.Sub(fsys, "keploy"); err == nil {
+			return sub
+		}
+	}
+	return fsys
+}
+
+// generate converts every test-set in the keploy file system into a Postman
+// collection and writes it, or the OpenAPI description derived from it, to disk.
+func generate(fsys fs.FS, opts options) error {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("reading the keploy directory: %w", err)
+	}
+	// Postman v2 collections have no ordering field; items run in array
+	// order, so keep test-sets and tests in the order keploy recorded them.
+	sortEntries(files)
 	collection := PostmanCollection{
-		Info: struct {
-			PostmanID  string `json:"_postman_id"`
-			Name       string `json:"name"`
-			Schema     string `json:"schema"`
-			ExporterID string `json:"_exporter_id"`
-		}{
-			PostmanID:  "b8623e1b69-224e-4ff3-801c-a95d480859bd",
-			Name:       "Atlantis",
-			Schema:     "https://schema.getpostman.com/json/collection/v2.0.0/collection.json",
-			ExporterID: "132182772",
+		Info: PostmanInfo{
+			PostmanID:  newUUID(),
+			Name:       opts.name,
+			Schema:     "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
+			ExporterID: opts.exporterId,
+			Version:    opts.version,
 		},
 	}
+	if opts.collectionId != "" {
+		collection.Info.PostmanID = opts.collectionId
+	}
+	if opts.docs {
+		collection.Info.Description = "API requests recorded by keploy, each with the response it returned as an example."
+	}
+	if opts.description != "" {
+		collection.Info.Description = opts.description
+	}
+	ctx := buildContext{}
+	if opts.statusMapping != "" {
+		ctx.statusMapping, err = loadStatusMapping(opts.statusMapping)
+		if err != nil {
+			return fmt.Errorf("reading status mapping: %w", err)
+		}
+	}
+	ctx.ignore, err = loadIgnoreFile(fsys)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", ignoreFileName, err)
+	}
+
+	testSets := []string{}
 	for _, v := range files {
-		if strings.Contains(v.Name(), "test-set") {
-			testsDir := filepath.Join(keployDir, v.Name(), "tests")
-			if _, err := os.Stat(testsDir); os.IsNotExist(err) {
-				fmt.Println("No 'tests' subfolder in:", v.Name())
-				continue
-			}
-			// Read the "tests" subfolder
-			testFiles, err := os.ReadDir(testsDir)
-			if err != nil {
-				fmt.Println("Error reading 'tests' directory:", err)
-				continue
-			}
-			testCases := []interface{}{}
-			for _, testFile := range testFiles {
-				if filepath.Ext(testFile.Name()) == ".yaml" {
-					filePath := filepath.Join(testsDir, testFile.Name())
+		if strings.Contains(v.Name(), "test-set") && !ctx.ignore.matches(v.Name()) {
+			testSets = append(testSets, v.Name())
+		}
+	}
+	if len(testSets) == 0 {
+		return errors.New("no test-set directories found")
+	}
+	if opts.latest {
+		testSets = []string{latestTestSet(fsys, testSets)}
+	}
+	results, err := buildTestSets(fsys, testSets, opts, ctx)
+	if err != nil {
+		return err
+	}
+	endpoints := []endpoint{}
+	report := runReport{TestSets: len(testSets), Skipped: []skippedTest{}, Warnings: []string{}}
+	for _, result := range results {
+		report.Tests += result.tests
+		report.Skipped = append(report.Skipped, result.skipped...)
+		report.Warnings = append(report.Warnings, result.warnings...)
+		if result.folder == nil {
+			continue
+		}
+		collection.Items = append(collection.Items, result.folder)
+		endpoints = append(endpoints, result.endpoints...)
+	}
 
-					// Read the YAML file
-					data, err := os.ReadFile(filePath)
-					if err != nil {
-						fmt.Println("Error reading file:", err)
-						continue
-					}
+	if opts.collapseSets {
+		collection.Items = collapseTestSets(collection.Items, opts.dedupeBy)
+	}
+	if opts.dedupe {
+		collection.Items = dedupeItems(collection.Items, opts.dedupeBy)
+	}
+	if opts.groupBy == groupByHost {
+		collection.Items = groupItemsByHost(collection.Items)
+	}
 
-					// Parse the YAML file (assuming it's a map for simplicity)
-					var yamlData map[string]interface{}
-					err = yaml.Unmarshal(data, &yamlData)
-					if err != nil {
-						fmt.Println("Error parsing YAML:", err)
-						continue
-					}
-					if curl, ok := yamlData["curl"].(string); ok {
-						requestJSON := parseCurlCommand(curl)
+	fillPathVariables(collection.Items)
+	if opts.seedVariables {
+		collection.Variables = append(collection.Variables, seedPathVariables(collection.Items)...)
+	}
 
-						testCases = append(testCases, requestJSON)
-					}
-				}
+	if opts.sessionCookie != "" {
+		if session := liftSessionCookie(collection.Items, opts.sessionCookie); session != "" {
+			collection.Variables = append(collection.Variables, map[string]string{"key": "session", "value": session})
+		} else {
+			fmt.Printf("No request sends a %s cookie\n", opts.sessionCookie)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("no request sends a %s cookie", opts.sessionCookie))
+		}
+	}
+
+	if opts.authEnv != "" {
+		token := os.Getenv(opts.authEnv)
+		if token == "" {
+			fmt.Printf("Environment variable %s is not set\n", opts.authEnv)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("environment variable %s is not set", opts.authEnv))
+		}
+		collection.Auth = bearerAuth("{{token}}")
+		collection.Variables = append(collection.Variables, map[string]string{"key": "token", "value": token})
+	}
+
+	if opts.openAPISpec != "" {
+		operations, err := loadOpenAPISpec(opts.openAPISpec)
+		if err != nil {
+			return fmt.Errorf("reading OpenAPI spec: %w", err)
+		}
+		applyOpenAPISpec(collection.Items, operations)
+	}
+
+	if opts.baseCollection != "" {
+		baseSignatures, err := loadBaseSignatures(opts.baseCollection, opts.dedupeBy)
+		if err != nil {
+			return fmt.Errorf("reading base collection: %w", err)
+		}
+		collection.Items = filterChangedItems(collection.Items, baseSignatures, opts.dedupeBy)
+	}
+
+	if opts.uniqueNames {
+		if collisions := duplicateNames(collection.Items, ""); len(collisions) > 0 {
+			return fmt.Errorf("duplicate request names: %s", strings.Join(collisions, ", "))
+		}
+	}
+
+	if opts.idScheme != "" {
+		assignItemIds(collection.Items, newIDGenerator(opts.idScheme), "")
+	}
+
+	// Take the recorded statuses off the items before anything renders them
+	inventory := collectionEndpoints(collection.Items)
+
+	var outputData []byte
+	outputFile := "output.json"
+	var bodies []bodyFile
+	switch opts.format {
+	case "openapi":
+		outputFile = "openapi.json"
+		outputData, err = json.MarshalIndent(buildOpenAPI(collection), "", "    ")
+	case "csv":
+		outputFile = "output.csv"
+		outputData, err = buildCSV(inventory)
+	case "k6":
+		outputFile = "script.js"
+		outputData = []byte(buildK6Script(collection))
+	default:
+		if opts.splitBodies {
+			target := outputFile
+			if opts.output != "" {
+				target = opts.output
 			}
-			requestFile := map[string]interface{}{
-				"name": v.Name(),
-				"item": testCases,
+			bodies, err = externalizeBodies(collection.Items, target)
+			if err != nil {
+				return fmt.Errorf("writing bodies: %w", err)
 			}
-			collection.Items = append(collection.Items, requestFile)
-
+			fmt.Printf("%d bodies written to %s\n", len(bodies), filepath.Join(filepath.Dir(target), "bodies"))
 		}
+		outputData, err = json.MarshalIndent(collection, "", "    ")
 	}
-	outputData, err := json.MarshalIndent(collection, "", "    ")
 	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
-		return
+		return fmt.Errorf("rendering %s output: %w", opts.format, err)
+	}
+	if opts.output != "" {
+		outputFile = opts.output
+	}
+	if opts.validateSchema && opts.format == "postman" {
+		violations, err := validateCollection(outputData)
+		if err != nil {
+			return fmt.Errorf("validating collection: %w", err)
+		}
+		if len(violations) > 0 {
+			return fmt.Errorf("collection does not match the Postman schema:\n  %s", strings.Join(violations, "\n  "))
+		}
+	}
+	if opts.gzip {
+		if outputData, err = gzipOutput(outputFile, outputData); err != nil {
+			return fmt.Errorf("compressing output: %w", err)
+		}
+		outputFile += ".gz"
 	}
 
-	if err := os.WriteFile("output.json", outputData, 0644); err != nil {
-		fmt.Println("Error writing JSON to file:", err)
-		return
+	if err := os.WriteFile(outputFile, outputData, 0644); err != nil {
+		return fmt.Errorf("writing output to file: %w", err)
 	}
 
-	fmt.Println("Data written to output.json")
+	fmt.Println("Data written to", outputFile)
 
-}
+	if opts.s3 != "" {
+		location, _ := parseS3Location(opts.s3)
+		contentType := mime.TypeByExtension(filepath.Ext(outputFile))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if err := uploadToS3(location, outputData, contentType); err != nil {
+			return fmt.Errorf("uploading to S3: %w", err)
+		}
+		fmt.Println("Uploaded to", location)
+		if err := uploadBodiesToS3(location, bodies); err != nil {
+			return fmt.Errorf("uploading to S3: %w", err)
+		}
+		if len(bodies) > 0 {
+			fmt.Printf("%d bodies uploaded next to %s\n", len(bodies), location)
+		}
+	}
 
-func ReadDir(path string, fileMode fs.FileMode) (*os.File, error) {
-	dir, err := os.OpenFile(path, os.O_RDONLY, fileMode)
-	if err != nil {
-		return nil, err
+	if opts.report != "" {
+		report.Output = outputFile
+		forEachRequest(collection.Items, func(map[string]interface{}) { report.Requests++ })
+		if err := writeReport(opts.report, report); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	}
+
+	if opts.coverage != "" {
+		if err := writeCoverage(opts.coverage, endpoints); err != nil {
+			return fmt.Errorf("writing coverage: %w", err)
+		}
+	}
+
+	if opts.split != "" {
+		// The split collections live in their own directory, so their file
+		// bodies point back at the ones next to the output
+		split := collection
+		if len(bodies) > 0 {
+			base, err := relativeDir(opts.split, filepath.Dir(outputFile))
+			if err != nil {
+				return fmt.Errorf("writing split collections: %w", err)
+			}
+			split.Items = rebaseBodyFiles(collection.Items, base)
+		}
+		manifest, err := writeSplit(opts.split, split)
+		if err != nil {
+			return fmt.Errorf("writing split collections: %w", err)
+		}
+		fmt.Printf("%d collections and %s written to %s\n", len(manifest), manifestFileName, opts.split)
+	}
+
+	if opts.bundle != "" {
+		if err := writeBundle(opts.bundle, collection, bodies); err != nil {
+			return fmt.Errorf("writing bundle: %w", err)
+		}
+		fmt.Println("Bundle written to", opts.bundle)
 	}
-	return dir, nil
+	return nil
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// duplicateNames lists the item names used more than once within the same
+// folder, as folder/name (xN).
+func duplicateNames(items []interface{}, folder string) []string {
+	counts := map[string]int{}
+	order := []string{}
+	collisions := []string{}
+	for _, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := item["name"].(string)
+		if children, ok := item["item"].([]interface{}); ok {
+			collisions = append(collisions, duplicateNames(children, path.Join(folder, name))...)
+		}
+		if counts[name] == 0 {
+			order = append(order, name)
+		}
+		counts[name]++
+	}
+	for _, name := range order {
+		if counts[name] > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s (x%d)", path.Join(folder, name), counts[name]))
+		}
+	}
+	return collisions
+}
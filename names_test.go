@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDuplicateNamesFailTheRun(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/users?page=2"),
+		"test-set-0/tests/test-3.yaml": keployTest("curl http://api/orders"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api/users"),
+	}
+	opts := testOptions()
+	opts.output = filepath.Join(t.TempDir(), "output.json")
+	if err := generate(fsys, opts); err != nil {
+		t.Fatalf("without -fail-on-duplicate-names: %v", err)
+	}
+	opts.uniqueNames = true
+	err := generate(fsys, opts)
+	if err == nil || !strings.Contains(err.Error(), "duplicate request names: test-set-0/users (x2)") {
+		t.Fatalf("err = %v, want the test-set-0/users collision listed", err)
+	}
+	if strings.Contains(err.Error(), "test-set-1") || strings.Contains(err.Error(), "orders") {
+		t.Errorf("err = %v, want only names repeated within one folder", err)
+	}
+}
+
+func TestDuplicateNamesExitNonZero(t *testing.T) {
+	dir := t.TempDir()
+	tests := filepath.Join(dir, "keploy", "test-set-0", "tests")
+	if err := os.MkdirAll(tests, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"test-1.yaml", "test-2.yaml"} {
+		if err := os.WriteFile(filepath.Join(tests, name), keployTest("curl http://api/users").Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, code := runMain(t, dir, "-fail-on-duplicate-names"); code == 0 || !strings.Contains(out, "test-set-0/users (x2)") {
+		t.Errorf("exit code %d, output:\n%s\nwant a failure naming the collision", code, out)
+	}
+}
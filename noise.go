@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// noiseFields holds the response fields keploy treats as noise, such as
+// "header.date" or "body.id", lowercased. Assertions on them are not emitted
+// because their values change from run to run.
+type noiseFields map[string]bool
+
+func (n noiseFields) header(name string) bool {
+	return n["header."+strings.ToLower(name)]
+}
+
+// merge returns the fields of both sets without modifying either.
+func (n noiseFields) merge(other noiseFields) noiseFields {
+	merged := noiseFields{}
+	for field := range n {
+		merged[field] = true
+	}
+	for field := range other {
+		merged[field] = true
+	}
+	return merged
+}
+
+// parseNoise reads noise in any of the shapes keploy writes: a list of field
+// paths, a map of paths to (ignored) value patterns, or maps nested by path
+// segment such as {header: {Date: []}}.
+func parseNoise(value interface{}, prefix string, into noiseFields) {
+	join := func(key interface{}) string {
+		if prefix == "" {
+			return strings.ToLower(fmt.Sprint(key))
+		}
+		return prefix + "." + strings.ToLower(fmt.Sprint(key))
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, field := range v {
+			if _, nested := field.(map[interface{}]interface{}); nested {
+				parseNoise(field, prefix, into)
+				continue
+			}
+			into[join(field)] = true
+		}
+	case map[interface{}]interface{}:
+		for key, patterns := range v {
+			if nested, ok := patterns.(map[interface{}]interface{}); ok && len(nested) > 0 {
+				parseNoise(nested, join(key), into)
+				continue
+			}
+			into[join(key)] = true
+		}
+	}
+}
+
+// loadTestSetNoise reads the noise fields declared in a test-set's
+// config.yaml, if it has one.
+func loadTestSetNoise(fsys fs.FS, testSet string) (noiseFields, error) {
+	data, err := fs.ReadFile(fsys, path.Join(testSet, "config.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return noiseFields{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	noise := noiseFields{}
+	parseNoise(config["noise"], "", noise)
+	return noise, nil
+}
+
+// testNoise returns the noise fields of one test: those of its test-set plus
+// any listed under spec.assertions.noise.
+func testNoise(setNoise noiseFields, yamlData map[string]interface{}) noiseFields {
+	own := noiseFields{}
+	parseNoise(mustYamlValue(yamlData, "spec.assertions.noise"), "", own)
+	if len(own) == 0 {
+		return setNoise
+	}
+	return setNoise.merge(own)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// buildOpenAPI describes every request in the collection as an OpenAPI 3
+// operation. Requests sharing a method and path template collapse into one
+// operation.
+func buildOpenAPI(collection PostmanCollection) map[string]interface{} {
+	paths := map[string]interface{}{}
+	servers := []map[string]string{}
+	seenServers := map[string]bool{}
+	usedIds := map[string]bool{}
+
+	templates := [][]string{}
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		if _, segments := requestPath(item); hasPathVariable(segments) {
+			templates = append(templates, segments)
+		}
+	})
+
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		request, ok := item["request"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		method, _ := request["method"].(string)
+		method = strings.ToLower(method)
+		if method == "" {
+			method = "get"
+		}
+		parsedUrl, err := requestURL(request)
+		if err != nil || parsedUrl.Host == "" {
+			return
+		}
+		server := parsedUrl.Scheme + "://" + parsedUrl.Host
+		if !seenServers[server] {
+			seenServers[server] = true
+			servers = append(servers, map[string]string{"url": server})
+		}
+		_, segments := requestPath(item)
+		path, pathParameters := openAPIPath(segments, templates)
+
+		pathItem, ok := paths[path].(map[string]interface{})
+		if !ok {
+			pathItem = map[string]interface{}{}
+			paths[path] = pathItem
+		}
+		if _, exists := pathItem[method]; exists {
+			return
+		}
+
+		operation := map[string]interface{}{
+			"operationId": uniqueOperationId(operationId(method, path), usedIds),
+			"responses": map[string]interface{}{
+				"default": map[string]interface{}{"description": "Recorded response"},
+			},
+		}
+		if name, ok := item["name"].(string); ok && name != "" {
+			operation["summary"] = name
+		}
+		query := parsedUrl.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parameters := []map[string]interface{}{}
+		for _, name := range pathParameters {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]string{"type": "string"},
+			})
+		}
+		for _, key := range keys {
+			parameters = append(parameters, map[string]interface{}{
+				"name":   key,
+				"in":     "query",
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if body, ok := request["body"].(map[string]interface{}); ok {
+			if raw, _ := body["raw"].(string); raw != "" {
+				contentType := requestHeader(request, "Content-Type")
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				operation["requestBody"] = map[string]interface{}{
+					"content": map[string]interface{}{
+						contentType: map[string]interface{}{"example": raw},
+					},
+				}
+			}
+		}
+		pathItem[method] = operation
+	})
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   collection.Info.Name,
+			"version": "1.0.0",
+		},
+		"servers": servers,
+		"paths":   paths,
+	}
+}
+
+// openAPIPath turns a request path into an OpenAPI path template and the
+// names of its parameters. :name and {{name}} variables become {name}; a
+// concrete path takes the names of a recorded template it fits, so
+// /users/42 joins /users/:id as /users/{id}, and otherwise numeric and UUID
+// segments become {id}, {id2} and so on.
+func openAPIPath(segments []string, templates [][]string) (string, []string) {
+	if !hasPathVariable(segments) {
+		for _, template := range templates {
+			if matchesTemplate(template, segments) {
+				segments = template
+				break
+			}
+		}
+	}
+	parts := make([]string, len(segments))
+	parameters := []string{}
+	for i, segment := range segments {
+		name := ""
+		switch {
+		case strings.HasPrefix(segment, ":") && len(segment) > 1:
+			name = segment[1:]
+		case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}") && len(segment) > 4:
+			name = segment[2 : len(segment)-2]
+		case isUUID(segment) || (segment != "" && strings.Trim(segment, "0123456789") == ""):
+			name = "id"
+			if n := countIdParameters(parameters); n > 0 {
+				name = fmt.Sprintf("id%d", n+1)
+			}
+		}
+		if name == "" {
+			parts[i] = segment
+			continue
+		}
+		parts[i] = "{" + name + "}"
+		parameters = append(parameters, name)
+	}
+	return "/" + strings.Join(parts, "/"), parameters
+}
+
+func countIdParameters(names []string) int {
+	count := 0
+	for _, name := range names {
+		if name == "id" || (strings.HasPrefix(name, "id") && strings.Trim(name[2:], "0123456789") == "") {
+			count++
+		}
+	}
+	return count
+}
+
+// operationId derives a camelCased identifier from the method and path, so
+// POST /users/{id}/orders becomes postUsersIdOrders.
+func operationId(method, path string) string {
+	words := strings.FieldsFunc(method+" "+path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var id strings.Builder
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		id.WriteString(word)
+	}
+	return id.String()
+}
+
+// uniqueOperationId appends a numeric suffix when two different operations
+// camelCase to the same identifier.
+func uniqueOperationId(id string, used map[string]bool) string {
+	candidate := id
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", id, n)
+	}
+	used[candidate] = true
+	return candidate
+}
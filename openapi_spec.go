@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type specOperation struct {
+	method string
+	// path is the operation's path as the spec writes it, and segments that
+	// path split after any server base path
+	path        string
+	segments    []string
+	operationId string
+	summary     string
+	description string
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// loadOpenAPISpec reads the operations of an OpenAPI spec in YAML or JSON.
+func loadOpenAPISpec(path string) ([]specOperation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec struct {
+		Servers []struct {
+			URL string `yaml:"url"`
+		} `yaml:"servers"`
+		BasePath string                            `yaml:"basePath"`
+		Paths    map[string]map[string]interface{} `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	// Operations are matched against request paths with and without the
+	// base path the spec declares for its servers
+	prefixes := []string{""}
+	if spec.BasePath != "" {
+		prefixes = append(prefixes, spec.BasePath)
+	}
+	for _, server := range spec.Servers {
+		if serverUrl, err := url.Parse(server.URL); err == nil && strings.Trim(serverUrl.Path, "/") != "" {
+			prefixes = append(prefixes, serverUrl.Path)
+		}
+	}
+
+	specPaths := make([]string, 0, len(spec.Paths))
+	for specPath := range spec.Paths {
+		specPaths = append(specPaths, specPath)
+	}
+	sort.Strings(specPaths)
+	operations := []specOperation{}
+	for _, specPath := range specPaths {
+		pathItem := spec.Paths[specPath]
+		for _, method := range openAPIMethods {
+			fields, ok := pathItem[method].(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+			operation := specOperation{method: strings.ToUpper(method), path: specPath}
+			operation.operationId, _ = fields["operationId"].(string)
+			operation.summary, _ = fields["summary"].(string)
+			operation.description, _ = fields["description"].(string)
+			for _, prefix := range prefixes {
+				operation.segments = strings.Split(strings.Trim(strings.TrimRight(prefix, "/")+specPath, "/"), "/")
+				operations = append(operations, operation)
+			}
+		}
+	}
+
+	// Order the operations so the first one matching a request is the most
+	// specific: more literal segments first, then literals further left, as
+	// in /users/me before /users/{id}, then by path
+	sort.SliceStable(operations, func(i, j int) bool {
+		a, b := operations[i].segments, operations[j].segments
+		if la, lb := literalSegments(a), literalSegments(b); la != lb {
+			return la > lb
+		}
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if ta, tb := isSpecTemplate(a[k]), isSpecTemplate(b[k]); ta != tb {
+				return tb
+			}
+		}
+		if ja, jb := strings.Join(a, "/"), strings.Join(b, "/"); ja != jb {
+			return ja < jb
+		}
+		return operations[i].path < operations[j].path
+	})
+	return operations, nil
+}
+
+// applyOpenAPISpec names every request after the spec operation with the same
+// method and path, using its summary (or operationId) and description.
+func applyOpenAPISpec(items []interface{}, operations []specOperation) {
+	forEachRequest(items, func(item map[string]interface{}) {
+		method, segments := requestPath(item)
+		if segments == nil {
+			return
+		}
+		// The operations come most specific first, so the first match wins
+		var match *specOperation
+		for i, operation := range operations {
+			if operation.method == method && matchesSpecPath(operation.segments, segments) {
+				match = &operations[i]
+				break
+			}
+		}
+		if match == nil {
+			return
+		}
+		if match.summary != "" {
+			item["name"] = match.summary
+		} else if match.operationId != "" {
+			item["name"] = match.operationId
+		}
+		appendDescription(item, match.description)
+	})
+}
+
+func literalSegments(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if !isSpecTemplate(segment) {
+			count++
+		}
+	}
+	return count
+}
+
+// matchesSpecPath reports whether a request path fits an OpenAPI path, where
+// {name} templates match any segment, including a :name request variable.
+func matchesSpecPath(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, segment := range template {
+		if isSpecTemplate(segment) {
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSpecTemplate reports whether an OpenAPI path segment is a {name} template.
+func isSpecTemplate(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
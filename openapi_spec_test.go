@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyOpenAPISpecNamesMatchingRequests(t *testing.T) {
+	spec := `openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      summary: Fetch a user
+      description: Returns one user.
+  /users/me:
+    get:
+      operationId: getCurrentUser
+  /orders:
+    post:
+      operationId: createOrder
+`
+	specPath := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	operations, err := loadOpenAPISpec(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := testCollection(t,
+		`curl http://api/v1/users/42`,
+		`curl http://api/users/me`,
+		`curl http://api/orders -d '{}'`,
+		`curl http://api/orders`,
+	)
+	applyOpenAPISpec(collection.Items, operations)
+	requests := collection.Items[0].(map[string]interface{})["item"].([]interface{})
+	tests := []struct{ name, description string }{
+		{"Fetch a user", "Returns one user."},
+		{"getCurrentUser", ""},
+		{"createOrder", ""},
+		// No spec operation for GET /orders, so the path name stays
+		{"orders", ""},
+	}
+	for i, want := range tests {
+		item := requests[i].(map[string]interface{})
+		description, _ := testRequest(item)["description"].(string)
+		if item["name"] != want.name || description != want.description {
+			t.Errorf("request %d = %q (%q), want %q (%q)", i, item["name"], description, want.name, want.description)
+		}
+	}
+}
+
+func TestOverlappingSpecPathsMatchDeterministically(t *testing.T) {
+	spec := `openapi: 3.0.3
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+  /users/me:
+    get:
+      operationId: getCurrentUser
+  /{kind}/me:
+    get:
+      operationId: getCurrentThing
+  /users/{id}/{tab}:
+    get:
+      operationId: getUserTab
+  /{kind}/me/{tab}:
+    get:
+      operationId: getCurrentThingTab
+  /{kind}/{id}/posts:
+    get:
+      operationId: getThingPosts
+  /users/{id}/posts:
+    get:
+      operationId: getUserPosts
+`
+	specPath := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"getCurrentUser", "getUser", "getCurrentThing", "getUserTab", "getCurrentThingTab", "getUserPosts", "getThingPosts"}
+	// Map order changes from run to run, so repeat until it would have shown
+	for run := 0; run < 50; run++ {
+		operations, err := loadOpenAPISpec(specPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		collection := testCollection(t,
+			`curl http://api/users/me`,
+			`curl http://api/users/42`,
+			`curl http://api/teams/me`,
+			`curl http://api/users/me/settings`,
+			`curl http://api/teams/me/settings`,
+			`curl http://api/users/42/posts`,
+			`curl http://api/teams/7/posts`,
+		)
+		applyOpenAPISpec(collection.Items, operations)
+		names := []string{}
+		forEachRequest(collection.Items, func(item map[string]interface{}) {
+			names = append(names, item["name"].(string))
+		})
+		if !reflect.DeepEqual(names, want) {
+			t.Fatalf("run %d: names = %q, want %q", run, names, want)
+		}
+	}
+}
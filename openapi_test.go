@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testCollection wraps parsed curl commands in a single test-set folder.
+func testCollection(t *testing.T, curls ...string) PostmanCollection {
+	t.Helper()
+	items := []interface{}{}
+	for _, curl := range curls {
+		item, err := parseCurlCommand(curl, parseOptions{})
+		if err != nil {
+			t.Fatalf("parseCurlCommand(%q): %v", curl, err)
+		}
+		items = append(items, item)
+	}
+	return PostmanCollection{
+		Info:  PostmanInfo{Name: "Atlantis"},
+		Items: []interface{}{map[string]interface{}{"name": "test-set-0", "item": items}},
+	}
+}
+
+// openAPIOperations lists the spec's operations as "method path" keys with
+// their operationIds.
+func openAPIOperations(spec map[string]interface{}) map[string]string {
+	ids := map[string]string{}
+	for path, v := range spec["paths"].(map[string]interface{}) {
+		for method, operation := range v.(map[string]interface{}) {
+			ids[method+" "+path] = operation.(map[string]interface{})["operationId"].(string)
+		}
+	}
+	return ids
+}
+
+func TestBuildOpenAPIOperationIds(t *testing.T) {
+	spec := buildOpenAPI(testCollection(t,
+		`curl --url http://api/users`,
+		`curl --url http://api/users --data '{"name":"a"}'`,
+		`curl --url http://api/users/:id/orders`,
+		`curl --url http://api/users_`,
+	))
+	want := map[string]string{
+		"get /users":             "getUsers",
+		"post /users":            "postUsers",
+		"get /users/{id}/orders": "getUsersIdOrders",
+		"get /users_":            "getUsers2",
+	}
+	if got := openAPIOperations(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("operationIds = %v, want %v", got, want)
+	}
+}
+
+func TestBuildOpenAPIPathTemplates(t *testing.T) {
+	spec := buildOpenAPI(testCollection(t,
+		`curl --url http://api/users/:userId`,
+		`curl --url http://api/users/42`,
+		`curl --url http://api/users/43`,
+		`curl --url http://api/orders/7/items/9f0c1c2e-8a4b-4c5d-9e6f-0a1b2c3d4e5f`,
+		`curl --url 'http://api/carts/{{cartId}}'`,
+	))
+	want := map[string]string{
+		"get /users/{userId}":          "getUsersUserid",
+		"get /orders/{id}/items/{id2}": "getOrdersIdItemsId2",
+		"get /carts/{cartId}":          "getCartsCartid",
+	}
+	if got := openAPIOperations(spec); !reflect.DeepEqual(got, want) {
+		t.Fatalf("operations = %v, want %v", got, want)
+	}
+
+	operation := spec["paths"].(map[string]interface{})["/orders/{id}/items/{id2}"].(map[string]interface{})["get"].(map[string]interface{})
+	names := []string{}
+	for _, parameter := range operation["parameters"].([]map[string]interface{}) {
+		if parameter["in"] != "path" || parameter["required"] != true {
+			t.Errorf("parameter %v is not a required path parameter", parameter)
+		}
+		names = append(names, parameter["name"].(string))
+	}
+	sort.Strings(names)
+	if want := []string{"id", "id2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("path parameters = %v, want %v", names, want)
+	}
+}
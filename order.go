@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/fs"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// sortEntries orders directory entries naturally, so test-2 sorts before
+// test-10 the way keploy numbers its recordings.
+func sortEntries(entries []fs.DirEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return naturalLess(entries[i].Name(), entries[j].Name())
+	})
+}
+
+// naturalLess compares two names treating runs of digits as numbers.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		digitsA, digitsB := leadingDigits(a), leadingDigits(b)
+		if digitsA != "" && digitsB != "" {
+			numA, _ := strconv.ParseUint(digitsA, 10, 64)
+			numB, _ := strconv.ParseUint(digitsB, 10, 64)
+			if numA != numB {
+				return numA < numB
+			}
+			a, b = a[len(digitsA):], b[len(digitsB):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// latestTestSet picks the most recent of the named test-sets: the one with the
+// highest numeric suffix (test-set-10 after test-set-9) when every name has
+// one, otherwise the most recently modified.
+func latestTestSet(fsys fs.FS, names []string) string {
+	numbered := true
+	for _, name := range names {
+		if trailingDigits(name) == "" {
+			numbered = false
+			break
+		}
+	}
+	latest := names[0]
+	if numbered {
+		for _, name := range names[1:] {
+			a, _ := strconv.ParseUint(trailingDigits(latest), 10, 64)
+			b, _ := strconv.ParseUint(trailingDigits(name), 10, 64)
+			if b > a {
+				latest = name
+			}
+		}
+		return latest
+	}
+	var newest time.Time
+	for _, name := range names {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newest) {
+			latest, newest = name, info.ModTime()
+		}
+	}
+	return latest
+}
+
+func trailingDigits(s string) string {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	return s[i:]
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLatestTestSet(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-2/tests/test-1.yaml":  keployTest("curl http://api/two"),
+		"test-set-9/tests/test-1.yaml":  keployTest("curl http://api/nine"),
+		"test-set-10/tests/test-1.yaml": keployTest("curl http://api/ten"),
+	}
+	opts := testOptions()
+	opts.latest = true
+	if got := strings.Join(itemNames(generateTestCollection(t, fsys, opts).Items, ""), " "); got != "test-set-10/ten" {
+		t.Errorf("-latest items = %s, want only test-set-10", got)
+	}
+
+	now := time.Now()
+	named := fstest.MapFS{
+		"smoke":      &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: now.Add(-time.Hour)},
+		"regression": &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: now},
+		"checkout":   &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: now.Add(-2 * time.Hour)},
+	}
+	if got := latestTestSet(named, []string{"smoke", "regression", "checkout"}); got != "regression" {
+		t.Errorf("latest unnumbered test-set = %s, want the most recently modified", got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// applyPathPrefix prepends prefix, such as "/v2", to the request's URL path
+// for APIs served behind a gateway the tests were recorded without.
+func applyPathPrefix(item map[string]interface{}, prefix string) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	request, _ := item["request"].(map[string]interface{})
+	urlBlock, ok := request["url"].(map[string]interface{})
+	if prefix == "/" || !ok {
+		return
+	}
+	parsedUrl, err := requestURL(request)
+	if err != nil {
+		return
+	}
+	if parsedUrl.RawPath != "" {
+		parsedUrl.RawPath = prefix + parsedUrl.RawPath
+	}
+	parsedUrl.Path = prefix + parsedUrl.Path
+	urlBlock["raw"] = rawUrlString(parsedUrl)
+	urlBlock["path"] = pathSegments(parsedUrl)
+}
+
+// normalizeHost lowercases the request's hostname and drops a port that is
+// the default for its scheme, so http://Example.com:80/ and
+// http://example.com/ end up as one URL.
+func normalizeHost(item map[string]interface{}) {
+	request, _ := item["request"].(map[string]interface{})
+	urlBlock, ok := request["url"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	parsedUrl, err := requestURL(request)
+	if err != nil {
+		return
+	}
+	hostname, port := strings.ToLower(parsedUrl.Hostname()), parsedUrl.Port()
+	if (parsedUrl.Scheme == "http" && port == "80") || (parsedUrl.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	parsedUrl.Host = hostname
+	if strings.Contains(hostname, ":") {
+		parsedUrl.Host = "[" + hostname + "]"
+	}
+	if port != "" {
+		parsedUrl.Host += ":" + port
+	}
+	urlBlock["raw"] = rawUrlString(parsedUrl)
+	urlBlock["protocol"] = parsedUrl.Scheme
+	urlBlock["host"] = []string{hostname}
+	urlBlock["port"] = port
+}
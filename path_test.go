@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApplyPathPrefix(t *testing.T) {
+	for _, prefix := range []string{"/v2", "v2/", "/v2/"} {
+		item, err := parseCurlCommand(`curl 'http://api:8080/users/1?page=2'`, parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		applyPathPrefix(item, prefix)
+		request := item["request"].(map[string]interface{})
+		if got, want := requestRawUrl(request), "http://api:8080/v2/users/1?page=2"; got != want {
+			t.Errorf("%q: raw = %s, want %s", prefix, got, want)
+		}
+		urlBlock := request["url"].(map[string]interface{})
+		if got := fmt.Sprint(urlBlock["path"]); got != "[v2 users 1]" {
+			t.Errorf("%q: path = %s, want [v2 users 1]", prefix, got)
+		}
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"HTTP://Example.com:80/":          "http://example.com/",
+		"https://API.Example.com:443/a?b": "https://api.example.com/a?b",
+		"http://Example.com:8080/":        "http://example.com:8080/",
+		"https://example.com:80/":         "https://example.com:80/",
+		"http://[::1]:80/users":           "http://[::1]/users",
+	}
+	for raw, want := range tests {
+		item, err := parseCurlCommand("curl '"+raw+"'", parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		normalizeHost(item)
+		request := item["request"].(map[string]interface{})
+		if got := requestRawUrl(request); got != want {
+			t.Errorf("%s: raw = %s, want %s", raw, got, want)
+		}
+		if urlBlock := request["url"].(map[string]interface{}); strings.ToLower(fmt.Sprint(urlBlock["host"])) != fmt.Sprint(urlBlock["host"]) || urlBlock["protocol"] != strings.SplitN(want, ":", 2)[0] {
+			t.Errorf("%s: url block = %v", raw, urlBlock)
+		}
+	}
+}
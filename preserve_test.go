@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSchemelessPreservedRawUrl(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl 'localhost:8080/users/1?expand=orders'", "spec:", "  resp:", "    status_code: 200"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl localhost:8080/users/2", "spec:", "  resp:", "    status_code: 200"),
+	}
+	opts := testOptions()
+	opts.preserveRawUrl = true
+	opts.groupBy = groupByHost
+	collection := generateTestCollection(t, fsys, opts)
+	if got := strings.Join(itemNames(collection.Items, ""), " "); got != "localhost:8080/users-1 localhost:8080/users-2" {
+		t.Errorf("items = %s, want both filed under localhost:8080", got)
+	}
+	if raw := requestRawUrl(testRequest(firstRequest(t, collection))); raw != "localhost:8080/users/1?expand=orders" {
+		t.Errorf("url.raw = %s, want it as recorded", raw)
+	}
+
+	opts.format = "csv"
+	if got, want := generateTestOutput(t, fsys, opts), "method,path,status\nGET,/users/1,200\nGET,/users/2,200\n"; got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+
+	opts.format = "openapi"
+	spec := generateTestOutput(t, fsys, opts)
+	for _, want := range []string{`"http://localhost:8080"`, `"/users/{id}"`, `"expand"`} {
+		if !strings.Contains(spec, want) {
+			t.Errorf("openapi lacks %s:\n%s", want, spec)
+		}
+	}
+}
+
+func TestPreserveRawUrlRejectsRawUrlRewrites(t *testing.T) {
+	for _, args := range [][]string{
+		{"-prefix-path", "/v2"},
+		{"-normalize-hosts"},
+		{"-query-array-style", "repeat"},
+		{"-query-from-body"},
+	} {
+		out, code := runMain(t, t.TempDir(), append([]string{"-preserve-raw-url"}, args...)...)
+		if code != 2 || !strings.Contains(out, "-preserve-raw-url cannot be combined with "+args[0]) {
+			t.Errorf("%v: exit %d, output %q; want exit 2 naming the conflict", args, code, out)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// reGoModule matches the module directive of a go.mod file, quoted or not.
+var reGoModule = regexp.MustCompile(`(?m)^\s*module\s+"?([^"\s]+)"?`)
+
+// reMajorVersion matches the /vN suffix of a major-versioned module path.
+var reMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// projectMetadata reads a default collection name, and a description when
+// one is recorded, from the project in dir: the last element of the go.mod
+// module path, or the name and description of package.json. It is best
+// effort; missing or malformed files yield empty values.
+func projectMetadata(dir string) (name, description string) {
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if matches := reGoModule.FindSubmatch(data); matches != nil {
+			modulePath := string(matches[1])
+			name = path.Base(modulePath)
+			if reMajorVersion.MatchString(name) && strings.Contains(modulePath, "/") {
+				name = path.Base(path.Dir(modulePath))
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			if name == "" {
+				name = pkg.Name
+			}
+			description = pkg.Description
+		}
+	}
+	return name, description
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectMetadata(t *testing.T) {
+	tests := []struct {
+		name                      string
+		files                     map[string]string
+		wantName, wantDescription string
+	}{
+		{"go.mod", map[string]string{"go.mod": "module github.com/acme/shop\n\ngo 1.21\n"}, "shop", ""},
+		{"major version", map[string]string{"go.mod": "// comment\nmodule \"github.com/acme/shop/v3\"\n"}, "shop", ""},
+		{"package.json", map[string]string{"package.json": `{"name": "storefront", "description": "The web shop"}`}, "storefront", "The web shop"},
+		{"both", map[string]string{"go.mod": "module example.com/api\n", "package.json": `{"name": "ui", "description": "Admin UI"}`}, "api", "Admin UI"},
+		{"malformed", map[string]string{"package.json": `{"name":`}, "", ""},
+		{"none", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if name, description := projectMetadata(dir); name != tt.wantName || description != tt.wantDescription {
+				t.Errorf("projectMetadata = %q, %q; want %q, %q", name, description, tt.wantName, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestCollectionNamedAfterTheProject(t *testing.T) {
+	dir := t.TempDir()
+	tests := filepath.Join(dir, "keploy", "test-set-0", "tests")
+	if err := os.MkdirAll(tests, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tests, "test-1.yaml"), keployTest("curl http://api/users").Data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/acme/shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		data, err := os.ReadFile(filepath.Join(dir, "output.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if out, code := runMain(t, dir); code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, out)
+	}
+	if output := read(); !strings.Contains(output, `"name": "shop"`) {
+		t.Errorf("collection is not named after the go.mod module:\n%s", output)
+	}
+	if out, code := runMain(t, dir, "-name", "Custom"); code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, out)
+	}
+	if output := read(); !strings.Contains(output, `"name": "Custom"`) {
+		t.Errorf("-name did not win over go.mod:\n%s", output)
+	}
+}
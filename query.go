@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// queryPair is one key=value pair of a query string, kept in its escaped form.
+type queryPair struct {
+	key   string
+	value string
+}
+
+// splitQuery splits a raw query string into pairs without reordering them.
+func splitQuery(rawQuery string) []queryPair {
+	pairs := []queryPair{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		pairs = append(pairs, queryPair{key, value})
+	}
+	return pairs
+}
+
+func joinQuery(pairs []queryPair) string {
+	parts := make([]string, len(pairs))
+	for i, pair := range pairs {
+		parts[i] = pair.key + "=" + pair.value
+	}
+	return strings.Join(parts, "&")
+}
+
+// queryParams lists the pairs of a raw query string in Postman's url.query
+// form, in order and with repeated keys kept, in the escaped form they take in
+// the raw URL.
+func queryParams(rawQuery string) []map[string]string {
+	params := []map[string]string{}
+	for _, pair := range splitQuery(rawQuery) {
+		params = append(params, map[string]string{"key": pair.key, "value": pair.value})
+	}
+	return params
+}
+
+// arrayKeyName returns the bare name of a query key, without any [] suffix.
+func arrayKeyName(key string) string {
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		key = unescaped
+	}
+	return strings.TrimSuffix(key, "[]")
+}
+
+// applyQueryArrayStyle rewrites list-valued query parameters in the style the
+// API expects: "repeat" sends a=1&a=2 and "brackets" sends a[]=1&a[]=2.
+func applyQueryArrayStyle(item map[string]interface{}, style string) {
+	request, _ := item["request"].(map[string]interface{})
+	urlBlock, ok := request["url"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	parsedUrl, err := requestURL(request)
+	if err != nil || parsedUrl.RawQuery == "" {
+		return
+	}
+
+	pairs := splitQuery(parsedUrl.RawQuery)
+	counts := map[string]int{}
+	for _, pair := range pairs {
+		counts[arrayKeyName(pair.key)]++
+	}
+	for i, pair := range pairs {
+		name := arrayKeyName(pair.key)
+		isList := counts[name] > 1 || name != pair.key
+		if !isList {
+			continue
+		}
+		switch style {
+		case "repeat":
+			pairs[i].key = url.QueryEscape(name)
+		case "brackets":
+			pairs[i].key = url.QueryEscape(name) + "[]"
+		}
+	}
+
+	parsedUrl.RawQuery = joinQuery(pairs)
+	urlBlock["raw"] = rawUrlString(parsedUrl)
+	urlBlock["query"] = queryParams(parsedUrl.RawQuery)
+}
+
+// moveBodyToQuery moves the form-encoded body of a GET request, which servers
+// ignore, into its query string after any parameters already there.
+func moveBodyToQuery(item map[string]interface{}) {
+	request, _ := item["request"].(map[string]interface{})
+	urlBlock, ok := request["url"].(map[string]interface{})
+	method, _ := request["method"].(string)
+	if !ok || !strings.EqualFold(method, "GET") {
+		return
+	}
+	pairs := []queryPair{}
+	switch body := requestBody(item); body["mode"] {
+	case "urlencoded":
+		fields, _ := body["urlencoded"].([]map[string]string)
+		for _, field := range fields {
+			pairs = append(pairs, queryPair{url.QueryEscape(field["key"]), url.QueryEscape(field["value"])})
+		}
+	case "raw":
+		raw, _ := body["raw"].(string)
+		contentType := strings.ToLower(requestHeader(request, "Content-Type"))
+		if contentType == "" || strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+			if raw = strings.TrimSpace(raw); looksFormEncoded(raw) {
+				pairs = splitQuery(raw)
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return
+	}
+	parsedUrl, err := requestURL(request)
+	if err != nil {
+		return
+	}
+
+	parsedUrl.RawQuery = joinQuery(append(splitQuery(parsedUrl.RawQuery), pairs...))
+	urlBlock["raw"] = rawUrlString(parsedUrl)
+	urlBlock["query"] = queryParams(parsedUrl.RawQuery)
+	request["body"] = map[string]interface{}{"mode": "raw", "raw": ""}
+	headers := []map[string]string{}
+	for _, header := range requestHeaders(item) {
+		if !strings.EqualFold(header["key"], "Content-Type") {
+			headers = append(headers, header)
+		}
+	}
+	setRequestHeaders(item, headers)
+}
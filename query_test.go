@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestApplyQueryArrayStyle(t *testing.T) {
+	tests := map[string]string{
+		"repeat":   "http://api/search?a=1&a=2&b=3&c=4",
+		"brackets": "http://api/search?a[]=1&a[]=2&b[]=3&c=4",
+	}
+	for style, want := range tests {
+		item, err := parseCurlCommand(`curl 'http://api/search?a=1&a=2&b[]=3&c=4'`, parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		applyQueryArrayStyle(item, style)
+		urlBlock := item["request"].(map[string]interface{})["url"].(map[string]interface{})
+		if urlBlock["raw"] != want {
+			t.Errorf("%s: url.raw = %v, want %s", style, urlBlock["raw"], want)
+		}
+		if query := urlBlock["query"].([]map[string]string); len(query) != 4 || query[1]["key"] != query[0]["key"] || query[3]["key"] != "c" {
+			t.Errorf("%s: url.query = %v", style, query)
+		}
+	}
+}
+
+func TestMoveBodyToQuery(t *testing.T) {
+	tests := []struct {
+		curl, want string
+	}{
+		{`curl -X GET 'http://api/search?page=1' -d 'q=a%20b&sort=asc'`, "http://api/search?page=1&q=a+b&sort=asc"},
+		{`curl -X GET http://api/search -H 'Content-Type: application/x-www-form-urlencoded' -d 'q=x'`, "http://api/search?q=x"},
+		{`curl -X GET http://api/search -H 'Content-Type: application/json' -d 'q=x'`, "http://api/search"},
+		{`curl -X GET http://api/search -d '{"q":"x"}'`, "http://api/search"},
+		{`curl -X POST http://api/search -d 'q=x'`, "http://api/search"},
+	}
+	for _, tt := range tests {
+		item, err := parseCurlCommand(tt.curl, parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := requestBody(item)["raw"]
+		moveBodyToQuery(item)
+		request := item["request"].(map[string]interface{})
+		if got := requestRawUrl(request); got != tt.want {
+			t.Errorf("%s: url = %s, want %s", tt.curl, got, tt.want)
+		}
+		moved := tt.want != "http://api/search"
+		if got := requestBody(item)["raw"]; moved && got != "" || !moved && got != body {
+			t.Errorf("%s: body = %q after the move", tt.curl, got)
+		}
+		if moved && requestHeader(request, "Content-Type") != "" {
+			t.Errorf("%s: Content-Type kept after the body moved to the query", tt.curl)
+		}
+	}
+}
+
+func TestQueryIsAnOrderedArrayPerTestSetFolder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl 'http://api/search?z=1&a=2&z=3&flag&empty='"),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api/users"),
+	}
+	collection := generateTestCollection(t, fsys, testOptions())
+	folders := []string{}
+	for _, v := range collection.Items {
+		folder := v.(map[string]interface{})
+		if _, ok := folder["item"].([]interface{}); !ok {
+			t.Errorf("top-level item %v is not a folder", folder["name"])
+		}
+		folders = append(folders, folder["name"].(string))
+	}
+	if got := strings.Join(folders, " "); got != "test-set-0 test-set-1" {
+		t.Errorf("folders = %s, want one per test-set", got)
+	}
+
+	query := testRequest(firstRequest(t, collection))["url"].(map[string]interface{})["query"].([]interface{})
+	got := []string{}
+	for _, v := range query {
+		param := v.(map[string]interface{})
+		got = append(got, fmt.Sprintf("%v=%v", param["key"], param["value"]))
+	}
+	if want := "z=1 a=2 z=3 flag= empty="; strings.Join(got, " ") != want {
+		t.Errorf("query = %s, want %s in recorded order", strings.Join(got, " "), want)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxArchiveSize caps how much of a downloaded archive is read into memory, so
+// a misconfigured -url cannot exhaust it.
+var maxArchiveSize int64 = 512 << 20
+
+// fetchArchive downloads a zip or (optionally gzipped) tar archive of keploy
+// tests. Zip archives are read in memory; tar archives are extracted to a
+// temporary directory that cleanup removes.
+func fetchArchive(rawUrl string, timeout time.Duration) (fsys fs.FS, cleanup func(), err error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(rawUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("GET %s: %s", rawUrl, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", rawUrl, err)
+	}
+	if int64(len(data)) > maxArchiveSize {
+		return nil, nil, fmt.Errorf("%s is larger than %d MiB", rawUrl, maxArchiveSize>>20)
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "goPost-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	if err := extractTar(bytes.NewReader(data), dir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return os.DirFS(dir), cleanup, nil
+}
+
+// extractTar writes the directories and regular files of a tar stream, which
+// may be gzip-compressed, under dir. Entries that would escape dir are rejected.
+func extractTar(r io.Reader, dir string) error {
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buffered
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q is outside the archive root", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testArchives returns the same keploy tests as a zip and a gzipped tar.
+func testArchives(t *testing.T, files map[string]string) (zipData, tarData []byte) {
+	t.Helper()
+	var zipBuf, tarBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	gz := gzip.NewWriter(&tarBuf)
+	tw := tar.NewWriter(gz)
+	for name, curl := range files {
+		data := keployTest(curl).Data
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, closer := range []interface{ Close() error }{zw, tw, gz} {
+		if err := closer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return zipBuf.Bytes(), tarBuf.Bytes()
+}
+
+func TestFetchArchive(t *testing.T) {
+	zipData, tarData := testArchives(t, map[string]string{
+		"keploy/test-set-0/tests/test-1.yaml": "curl http://api/users",
+		"keploy/test-set-1/tests/test-1.yaml": "curl -X DELETE http://api/users/1",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tests.zip":
+			w.Write(zipData)
+		case "/tests.tar.gz":
+			w.Write(tarData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, name := range []string{"tests.zip", "tests.tar.gz"} {
+		fsys, cleanup, err := fetchArchive(server.URL+"/"+name, time.Second)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		collection := generateTestCollection(t, keployRoot(fsys), testOptions())
+		cleanup()
+		if got, want := strings.Join(itemNames(collection.Items, ""), " "), "test-set-0/users test-set-1/users-1"; got != want {
+			t.Errorf("%s: items = %s, want %s", name, got, want)
+		}
+	}
+
+	if _, _, err := fetchArchive(server.URL+"/missing.zip", time.Second); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing archive: err = %v, want the 404 status", err)
+	}
+}
+
+func TestFetchArchiveRejectsOversizedDownloads(t *testing.T) {
+	zipData, _ := testArchives(t, map[string]string{"keploy/test-set-0/tests/test-1.yaml": "curl http://api/users"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zipData)
+	}))
+	defer server.Close()
+
+	defer func(size int64) { maxArchiveSize = size }(maxArchiveSize)
+	maxArchiveSize = int64(len(zipData)) - 1
+	if _, _, err := fetchArchive(server.URL, time.Second); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("err = %v, want the download rejected as too large", err)
+	}
+	maxArchiveSize = int64(len(zipData))
+	if _, _, err := fetchArchive(server.URL, time.Second); err != nil {
+		t.Errorf("archive of exactly the limit: %v", err)
+	}
+}
+
+func TestUrlCannotBeCombinedWithOtherInputs(t *testing.T) {
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-archive", "tests.zip", "-url", server.URL + "/tests.zip"}, "-url cannot be combined with -watch or -archive"},
+		{[]string{"-url", server.URL + "/tests.zip", "-archive", "tests.zip"}, "-url cannot be combined with -watch or -archive"},
+		{[]string{"-watch", "-url", server.URL + "/tests.zip"}, "-url cannot be combined with -watch or -archive"},
+		{[]string{"-watch", "-archive", "tests.zip"}, "-watch cannot be combined with -archive"},
+	}
+	for _, tt := range tests {
+		out, code := runMain(t, t.TempDir(), tt.args...)
+		if code != 2 || !strings.Contains(out, tt.want) {
+			t.Errorf("%v: exit %d, output %q, want exit 2 with %q", tt.args, code, out, tt.want)
+		}
+	}
+	if n := downloads.Load(); n != 0 {
+		t.Errorf("%d downloads made before rejecting the flags", n)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// runReport summarises a run for CI: what was read, what made it into the
+// collection and what was left out.
+type runReport struct {
+	Output   string        `json:"output"`
+	TestSets int           `json:"testSets"`
+	Tests    int           `json:"tests"`
+	Requests int           `json:"requests"`
+	Skipped  []skippedTest `json:"skipped"`
+	Warnings []string      `json:"warnings"`
+}
+
+// skippedTest is a test file, or a directory of them, that was left out.
+type skippedTest struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+func writeReport(path string, report runReport) error {
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReportStructure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml": keployTest("curl -X DELETE http://api/users/1"),
+		"test-set-0/tests/test-3.yaml": &fstest.MapFile{Data: []byte("spec:\n  summary: no curl\n")},
+		"test-set-1/mocks.yaml":        &fstest.MapFile{Data: []byte("kind: Mock\n")},
+	}
+	dir := t.TempDir()
+	opts := testOptions()
+	opts.output = filepath.Join(dir, "output.json")
+	opts.report = filepath.Join(dir, "report.json")
+	generateTestCollection(t, fsys, opts)
+	data, err := os.ReadFile(opts.report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"output":   opts.output,
+		"testSets": 2.0,
+		"tests":    3.0,
+		"requests": 2.0,
+		"skipped": []interface{}{
+			map[string]interface{}{"file": "test-set-0/tests/test-3.yaml", "reason": "no curl command"},
+		},
+		"warnings": []interface{}{"no tests directory in test-set-1"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report =\n%s\nwant %v", data, want)
+	}
+}
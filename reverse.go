@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// collectionToCurl reads a Postman collection and renders every request in
+// it as a curl command, each preceded by a comment naming the item.
+func collectionToCurl(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var collection struct {
+		Items []interface{} `json:"item"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	forEachRequest(collection.Items, func(item map[string]interface{}) {
+		if name, ok := item["name"].(string); ok && name != "" {
+			out.WriteString("# " + name + "\n")
+		}
+		out.WriteString(requestToCurl(item) + "\n\n")
+	})
+	return out.String(), nil
+}
+
+// requestToCurl renders a single Postman request item as a curl command.
+func requestToCurl(item map[string]interface{}) string {
+	method, rawUrl := "GET", ""
+	args := []string{}
+	switch request := item["request"].(type) {
+	case string:
+		rawUrl = request
+	case map[string]interface{}:
+		if m, ok := request["method"].(string); ok && m != "" {
+			method = m
+		}
+		rawUrl = requestRawUrl(request)
+		if headers, ok := request["header"].([]interface{}); ok {
+			for _, v := range headers {
+				header, _ := v.(map[string]interface{})
+				if disabled, _ := header["disabled"].(bool); disabled {
+					continue
+				}
+				key, _ := header["key"].(string)
+				value, _ := header["value"].(string)
+				args = append(args, "--header "+shellQuote(key+": "+value))
+			}
+		}
+		if auth, ok := request["auth"].(map[string]interface{}); ok {
+			if value := authHeader(auth); value != "" {
+				args = append(args, "--header "+shellQuote("Authorization: "+value))
+			}
+		}
+		if body, ok := request["body"].(map[string]interface{}); ok {
+			args = append(args, bodyToCurl(body)...)
+		}
+	}
+	// Anything beyond letters, digits and dashes could carry shell syntax
+	if strings.Trim(method, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+		method = shellQuote(method)
+	}
+	lines := append([]string{"curl --request " + method, "--url " + shellQuote(rawUrl)}, args...)
+	return strings.Join(lines, " \\\n  ")
+}
+
+func bodyToCurl(body map[string]interface{}) []string {
+	args := []string{}
+	mode, _ := body["mode"].(string)
+	switch mode {
+	case "raw":
+		if raw, _ := body["raw"].(string); raw != "" {
+			// --data would read a body starting with @ from a file
+			flag := "--data "
+			if strings.HasPrefix(raw, "@") {
+				flag = "--data-raw "
+			}
+			args = append(args, flag+shellQuote(raw))
+		}
+	case "urlencoded", "formdata":
+		fields, _ := body[mode].([]interface{})
+		for _, v := range fields {
+			field, _ := v.(map[string]interface{})
+			if disabled, _ := field["disabled"].(bool); disabled {
+				continue
+			}
+			key, _ := field["key"].(string)
+			value, _ := field["value"].(string)
+			if mode == "urlencoded" {
+				args = append(args, "--data-urlencode "+shellQuote(key+"="+value))
+				continue
+			}
+			fieldType, _ := field["type"].(string)
+			if fieldType == "file" {
+				src, _ := field["src"].(string)
+				value = "@" + src
+			} else if literalFormValue(value) {
+				// --form would read a value starting with @ or < from a file
+				// and split off anything after a ;, so send it verbatim; curl
+				// has no way to give such a part its own content type
+				args = append(args, "--form-string "+shellQuote(key+"="+value))
+				continue
+			}
+			if contentType, _ := field["contentType"].(string); contentType != "" {
+				value += ";type=" + contentType
+			}
+			args = append(args, "--form "+shellQuote(key+"="+value))
+		}
+	}
+	return args
+}
+
+// literalFormValue reports whether curl's --form would read a text value as
+// something other than itself: a file for a leading @ or <, a quoted value
+// for a leading ", or part parameters after a ;.
+func literalFormValue(value string) bool {
+	return strings.HasPrefix(value, "@") || strings.HasPrefix(value, "<") || strings.HasPrefix(value, "\"") || strings.Contains(value, ";")
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell, closing and
+// reopening the quotes around any embedded single quote:
+//
+//	it's -> 'it'\''s'
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Errorf("shellQuote = %s, want %s", got, want)
+	}
+}
+
+func TestRequestToCurlRoundTripsQuotes(t *testing.T) {
+	item, err := decodedItem(map[string]interface{}{
+		"request": map[string]interface{}{
+			"method": "POST",
+			"url":    map[string]interface{}{"raw": "http://api/notes"},
+			"header": []interface{}{map[string]interface{}{"key": "X-Note", "value": "it's 'quoted'"}},
+			"body":   map[string]interface{}{"mode": "raw", "raw": `{"text":"don't"}`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	curl := requestToCurl(item)
+	if !strings.Contains(curl, `--header 'X-Note: it'\''s '\''quoted'\'''`) {
+		t.Errorf("header not shell-quoted:\n%s", curl)
+	}
+	args := parseCurlArgs(curlWords(curl))
+	if len(args.headers) != 1 || args.headers[0] != "X-Note: it's 'quoted'" {
+		t.Errorf("headers read back as %q", args.headers)
+	}
+	if len(args.data) != 1 || args.data[0] != `{"text":"don't"}` {
+		t.Errorf("body read back as %q", args.data)
+	}
+}
+
+func TestBodyToCurlSendsLiteralFormValuesVerbatim(t *testing.T) {
+	item, err := decodedItem(map[string]interface{}{
+		"request": map[string]interface{}{
+			"method": "POST",
+			"url":    map[string]interface{}{"raw": "http://api/upload"},
+			"body": map[string]interface{}{"mode": "formdata", "formdata": []interface{}{
+				map[string]interface{}{"key": "doc", "type": "text", "value": "<root>hi</root>", "contentType": "application/xml"},
+				map[string]interface{}{"key": "handle", "type": "text", "value": "@someone"},
+				map[string]interface{}{"key": "note", "type": "text", "value": "a;b"},
+				map[string]interface{}{"key": "name", "type": "text", "value": "plain", "contentType": "text/plain"},
+				map[string]interface{}{"key": "file", "type": "file", "src": "report.pdf"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := bodyToCurl(testBody(item))
+	want := []string{
+		`--form-string 'doc=<root>hi</root>'`,
+		`--form-string 'handle=@someone'`,
+		`--form-string 'note=a;b'`,
+		`--form 'name=plain;type=text/plain'`,
+		`--form 'file=@report.pdf'`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("bodyToCurl =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRequestToCurlQuotesHostileMethods(t *testing.T) {
+	tests := map[string]string{
+		"PATCH":         "curl --request PATCH ",
+		"GET; rm -rf ~": `curl --request 'GET; rm -rf ~' `,
+		"GET$(id)":      `curl --request 'GET$(id)' `,
+		"GET`id`":       "curl --request 'GET`id`' ",
+		"GET' && 'echo": `curl --request 'GET'\'' && '\''echo' `,
+		"GET\nrm -rf ~": "curl --request 'GET\nrm -rf ~' ",
+	}
+	for method, want := range tests {
+		item, err := decodedItem(map[string]interface{}{
+			"request": map[string]interface{}{"method": method, "url": map[string]interface{}{"raw": "http://api/users"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		curl := requestToCurl(item)
+		if !strings.HasPrefix(curl, want) {
+			t.Errorf("%q: curl = %s, want it to start with %s", method, curl, want)
+		}
+		if words := curlWords(curl); len(words) < 3 || words[2] != method {
+			t.Errorf("%q: method read back as %q", method, words)
+		}
+	}
+}
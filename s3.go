@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// s3Location is a bucket and object key named as s3://bucket/key.
+type s3Location struct {
+	bucket, key string
+}
+
+// parseS3Location splits an s3://bucket/key URL.
+func parseS3Location(location string) (s3Location, error) {
+	rest, ok := strings.CutPrefix(location, "s3://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return s3Location{}, fmt.Errorf("expected s3://bucket/key, got %q", location)
+	}
+	return s3Location{bucket: bucket, key: key}, nil
+}
+
+func (l s3Location) String() string {
+	return "s3://" + l.bucket + "/" + l.key
+}
+
+// s3Credentials are read from the standard AWS environment variables.
+// AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) points uploads at an
+// S3-compatible service such as MinIO instead of AWS.
+type s3Credentials struct {
+	accessKey, secretKey, sessionToken string
+	region, endpoint                   string
+}
+
+func s3CredentialsFromEnv() (s3Credentials, error) {
+	creds := s3Credentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       os.Getenv("AWS_REGION"),
+		endpoint:     os.Getenv("AWS_ENDPOINT_URL_S3"),
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return creds, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	if creds.region == "" {
+		creds.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if creds.region == "" {
+		creds.region = "us-east-1"
+	}
+	if creds.endpoint == "" {
+		creds.endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	return creds, nil
+}
+
+// objectUrl addresses the object virtual-hosted style on AWS, and path style
+// on custom endpoints and for bucket names with dots, which do not fit the
+// bucket certificate.
+func (c s3Credentials) objectUrl(l s3Location) (*url.URL, error) {
+	path := "/" + awsEscapePath(l.key)
+	if c.endpoint == "" {
+		if strings.Contains(l.bucket, ".") {
+			return url.Parse(fmt.Sprintf("https://s3.%s.amazonaws.com/%s%s", c.region, l.bucket, path))
+		}
+		return url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", l.bucket, c.region, path))
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(c.endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", c.endpoint)
+	}
+	return url.Parse(endpoint.String() + "/" + awsEscapePath(l.bucket) + path)
+}
+
+// uploadToS3 PUTs data as the object at location, signing the request with
+// AWS Signature Version 4.
+func uploadToS3(location s3Location, data []byte, contentType string) error {
+	creds, err := s3CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+	objectUrl, err := creds.objectUrl(location)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, objectUrl.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	signS3Request(req, data, creds, time.Now().UTC())
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("PUT %s: %s %s", location, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// uploadBodiesToS3 uploads the bodies written by -split-bodies-to-files next
+// to the collection object at location, where its file bodies reference them.
+func uploadBodiesToS3(location s3Location, bodies []bodyFile) error {
+	for _, body := range bodies {
+		bodyLocation := s3Location{bucket: location.bucket, key: path.Join(path.Dir(location.key), body.src)}
+		contentType := mime.TypeByExtension(path.Ext(body.src))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if err := uploadToS3(bodyLocation, body.data, contentType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signS3Request adds the x-amz-* headers and the Authorization header of an
+// AWS Signature Version 4 for the S3 service.
+func signS3Request(req *http.Request, payload []byte, creds s3Credentials, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	// Sign the host and every x-amz-* header, in lowercase name order
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if creds.sessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+		values["x-amz-security-token"] = creds.sessionToken
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + creds.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + creds.secretKey)
+	for _, part := range []string{date, creds.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKey, scope, signedHeaders, signature))
+}
+
+// awsEscapePath percent-encodes an object key the way SigV4 expects: every
+// byte but unreserved characters and slashes.
+func awsEscapePath(path string) string {
+	var out strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			(c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			out.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&out, "%%%02X", c)
+	}
+	return out.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
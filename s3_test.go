@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSignS3Request(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, "http://s3.example.test:9000/my-bucket/collections/out%20put.json", strings.NewReader(`{"info":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := s3Credentials{accessKey: "AKID", secretKey: "SECRETKEY", sessionToken: "TOKEN", region: "eu-west-1"}
+	signS3Request(req, []byte(`{"info":{}}`), creds, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, " +
+		"Signature=4486dc2ac23759e9b08bec1f6af6073ef60d88892e5eeeacab8494be0691cb21"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if req.Header.Get("X-Amz-Date") != "20240102T030405Z" || req.Header.Get("X-Amz-Security-Token") != "TOKEN" {
+		t.Errorf("x-amz headers = %v", req.Header)
+	}
+}
+
+// stubS3 is an S3-compatible endpoint that checks the SigV4 signature of
+// every PUT against its own secret and keeps the objects it accepted.
+type stubS3 struct {
+	secret  string
+	mu      sync.Mutex
+	objects map[string]string
+	types   map[string]string
+}
+
+func (s *stubS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Method != http.MethodPut {
+		http.Error(w, "<Error><Code>MethodNotAllowed</Code></Error>", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) || !s.validSignature(r) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[r.URL.EscapedPath()] = string(body)
+	s.types[r.URL.EscapedPath()] = r.Header.Get("Content-Type")
+}
+
+// validSignature recomputes the signature from the request as it arrived.
+func (s *stubS3) validSignature(r *http.Request) bool {
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "), ", ") {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			fields[key] = value
+		}
+	}
+	scope := strings.SplitN(fields["Credential"], "/", 2)
+	if len(scope) != 2 || !strings.HasSuffix(scope[1], "/s3/aws4_request") {
+		return false
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(fields["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.RawQuery, canonicalHeaders.String(),
+		fields["SignedHeaders"], r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	parts := strings.Split(scope[1], "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", r.Header.Get("X-Amz-Date"), scope[1], sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := []byte("AWS4" + s.secret)
+	for _, part := range parts {
+		key = hmacSHA256(key, part)
+	}
+	want := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	return hmac.Equal([]byte(want), []byte(fields["Signature"]))
+}
+
+func TestUploadToStubS3(t *testing.T) {
+	stub := &stubS3{secret: "SECRETKEY", objects: map[string]string{}, types: map[string]string{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRETKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	fsys := fstest.MapFS{"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users")}
+	opts := testOptions()
+	opts.output = filepath.Join(t.TempDir(), "output.json")
+	opts.s3 = "s3://my-bucket/collections/out put.json"
+	if err := generate(fsys, opts); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	written, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const object = "/my-bucket/collections/out%20put.json"
+	if got := stub.objects[object]; got != string(written) {
+		t.Errorf("uploaded objects = %v, want the output at %s", stub.objects, object)
+	}
+	if got := stub.types[object]; got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	// A session token is signed too, and a wrong secret is rejected
+	t.Setenv("AWS_SESSION_TOKEN", "TOKEN")
+	if err := uploadToS3(s3Location{bucket: "my-bucket", key: "a.json"}, []byte("{}"), "application/json"); err != nil {
+		t.Errorf("upload with a session token: %v", err)
+	}
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "WRONG")
+	err = uploadToS3(s3Location{bucket: "my-bucket", key: "b.json"}, []byte("{}"), "application/json")
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("upload with the wrong secret: err = %v, want the 403 and its error code", err)
+	}
+	if _, ok := stub.objects["/my-bucket/b.json"]; ok {
+		t.Error("the stub stored an object with a bad signature")
+	}
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if err := uploadToS3(s3Location{bucket: "my-bucket", key: "c.json"}, []byte("{}"), "application/json"); err == nil {
+		t.Error("upload without credentials succeeded")
+	}
+	server.Close()
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRETKEY")
+	if err := uploadToS3(s3Location{bucket: "my-bucket", key: "d.json"}, []byte("{}"), "application/json"); err == nil {
+		t.Error("upload to a closed endpoint succeeded")
+	}
+}
+
+func TestParseS3Location(t *testing.T) {
+	if l, err := parseS3Location("s3://bucket/a/b.json"); err != nil || l.bucket != "bucket" || l.key != "a/b.json" {
+		t.Errorf("parseS3Location = %+v, %v", l, err)
+	}
+	for _, bad := range []string{"bucket/key", "s3://bucket", "s3://bucket/", "s3:///key"} {
+		if _, err := parseS3Location(bad); err == nil {
+			t.Errorf("parseS3Location(%q) succeeded", bad)
+		}
+	}
+}
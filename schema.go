@@ -0,0 +1,413 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// collectionSchema is the official Postman collection v2.1.0 JSON schema, as
+// published at https://schema.getpostman.com/json/collection/v2.1.0/collection.json.
+//
+//go:embed collection_schema.json
+var collectionSchema []byte
+
+// schemaValidator checks decoded JSON against a draft-04 JSON schema. It
+// implements the validation keywords of draft-04; keywords from later drafts,
+// such as const, are ignored the way a draft-04 validator ignores any unknown
+// keyword, and format is treated as an annotation.
+type schemaValidator struct {
+	root     map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// validateCollection checks an encoded collection against the embedded schema
+// and returns one message per violation, each prefixed with its JSON path.
+func validateCollection(data []byte) ([]string, error) {
+	return validateJSON(collectionSchema, data)
+}
+
+// validateJSON checks an encoded document against an encoded draft-04 schema.
+func validateJSON(schemaData, data []byte) ([]string, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	validator := &schemaValidator{root: schema, patterns: map[string]*regexp.Regexp{}}
+	return validator.validate(schema, document, "$"), nil
+}
+
+func (s *schemaValidator) validate(node interface{}, value interface{}, path string) []string {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	// Everything next to a $ref is ignored in draft-04
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}
+		}
+		return s.validate(target, value, path)
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesAnyType(value, types) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonType(value))}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(value, enum) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", path, value, enum)}
+	}
+
+	violations := []string{}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, branch := range allOf {
+			violations = append(violations, s.validate(branch, value, path)...)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		violations = append(violations, s.validateBranches(anyOf, value, path, false)...)
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		violations = append(violations, s.validateBranches(oneOf, value, path, true)...)
+	}
+	if not, ok := schema["not"]; ok && len(s.validate(not, value, path)) == 0 {
+		violations = append(violations, fmt.Sprintf("%s: matches a schema it must not match", path))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, s.validateObject(schema, v, path)...)
+	case []interface{}:
+		violations = append(violations, s.validateArray(schema, v, path)...)
+	case float64:
+		violations = append(violations, validateNumber(schema, v, path)...)
+	case string:
+		violations = append(violations, s.validateString(schema, v, path)...)
+	}
+	return violations
+}
+
+// resolve follows a $ref within the schema document, a JSON pointer such as
+// #/definitions/item.
+func (s *schemaValidator) resolve(ref string) (interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema reference %s", ref)
+	}
+	var node interface{} = s.root
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch current := node.(type) {
+		case map[string]interface{}:
+			next, ok := current[token]
+			if !ok {
+				return nil, fmt.Errorf("unknown schema reference %s", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil, fmt.Errorf("unknown schema reference %s", ref)
+			}
+			node = current[i]
+		default:
+			return nil, fmt.Errorf("unknown schema reference %s", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *schemaValidator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) []string {
+	violations := []string{}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				if _, present := object[name]; !present {
+					violations = append(violations, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+	}
+	if limit, ok := schemaNumber(schema, "minProperties"); ok && float64(len(object)) < limit {
+		violations = append(violations, fmt.Sprintf("%s: has %d properties, fewer than %v", path, len(object), limit))
+	}
+	if limit, ok := schemaNumber(schema, "maxProperties"); ok && float64(len(object)) > limit {
+		violations = append(violations, fmt.Sprintf("%s: has %d properties, more than %v", path, len(object), limit))
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	patterns := schemaKeys(patternProperties)
+	for _, key := range schemaKeys(object) {
+		keyPath := path + "." + key
+		matched := false
+		if property, ok := properties[key]; ok {
+			matched = true
+			violations = append(violations, s.validate(property, object[key], keyPath)...)
+		}
+		for _, pattern := range patterns {
+			re, err := s.pattern(pattern)
+			if err != nil {
+				violations = append(violations, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			if re.MatchString(key) {
+				matched = true
+				violations = append(violations, s.validate(patternProperties[pattern], object[key], keyPath)...)
+			}
+		}
+		if matched {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, fmt.Sprintf("%s: unexpected property %q", path, key))
+			}
+		case map[string]interface{}:
+			violations = append(violations, s.validate(additional, object[key], keyPath)...)
+		}
+	}
+
+	dependencies, _ := schema["dependencies"].(map[string]interface{})
+	for _, key := range schemaKeys(dependencies) {
+		if _, present := object[key]; !present {
+			continue
+		}
+		switch dependency := dependencies[key].(type) {
+		case []interface{}:
+			for _, needed := range dependency {
+				if name, ok := needed.(string); ok {
+					if _, present := object[name]; !present {
+						violations = append(violations, fmt.Sprintf("%s: property %q requires %q", path, key, name))
+					}
+				}
+			}
+		case map[string]interface{}:
+			violations = append(violations, s.validate(dependency, object, path)...)
+		}
+	}
+	return violations
+}
+
+func (s *schemaValidator) validateArray(schema map[string]interface{}, array []interface{}, path string) []string {
+	violations := []string{}
+	switch items := schema["items"].(type) {
+	case map[string]interface{}:
+		for i, element := range array {
+			violations = append(violations, s.validate(items, element, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case []interface{}:
+		// A list of schemas validates the array position by position, with
+		// additionalItems covering anything past the end of the list
+		for i, element := range array {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			if i < len(items) {
+				violations = append(violations, s.validate(items[i], element, elementPath)...)
+			} else if additional, ok := schema["additionalItems"].(map[string]interface{}); ok {
+				violations = append(violations, s.validate(additional, element, elementPath)...)
+			}
+		}
+		if additional, ok := schema["additionalItems"].(bool); ok && !additional && len(array) > len(items) {
+			violations = append(violations, fmt.Sprintf("%s: has %d items, at most %d allowed", path, len(array), len(items)))
+		}
+	}
+	if limit, ok := schemaNumber(schema, "minItems"); ok && float64(len(array)) < limit {
+		violations = append(violations, fmt.Sprintf("%s: has %d items, fewer than %v", path, len(array), limit))
+	}
+	if limit, ok := schemaNumber(schema, "maxItems"); ok && float64(len(array)) > limit {
+		violations = append(violations, fmt.Sprintf("%s: has %d items, more than %v", path, len(array), limit))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					violations = append(violations, fmt.Sprintf("%s: items %d and %d are equal", path, i, j))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+func validateNumber(schema map[string]interface{}, number float64, path string) []string {
+	violations := []string{}
+	if minimum, ok := schemaNumber(schema, "minimum"); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && number <= minimum {
+			violations = append(violations, fmt.Sprintf("%s: %v is not above the minimum %v", path, number, minimum))
+		} else if number < minimum {
+			violations = append(violations, fmt.Sprintf("%s: %v is below the minimum %v", path, number, minimum))
+		}
+	}
+	if maximum, ok := schemaNumber(schema, "maximum"); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && number >= maximum {
+			violations = append(violations, fmt.Sprintf("%s: %v is not below the maximum %v", path, number, maximum))
+		} else if number > maximum {
+			violations = append(violations, fmt.Sprintf("%s: %v is above the maximum %v", path, number, maximum))
+		}
+	}
+	if divisor, ok := schemaNumber(schema, "multipleOf"); ok && divisor > 0 {
+		if quotient := number / divisor; quotient != math.Trunc(quotient) {
+			violations = append(violations, fmt.Sprintf("%s: %v is not a multiple of %v", path, number, divisor))
+		}
+	}
+	return violations
+}
+
+func (s *schemaValidator) validateString(schema map[string]interface{}, str string, path string) []string {
+	violations := []string{}
+	length := float64(utf8.RuneCountInString(str))
+	if limit, ok := schemaNumber(schema, "minLength"); ok && length < limit {
+		violations = append(violations, fmt.Sprintf("%s: shorter than %v characters", path, limit))
+	}
+	if limit, ok := schemaNumber(schema, "maxLength"); ok && length > limit {
+		violations = append(violations, fmt.Sprintf("%s: longer than %v characters", path, limit))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := s.pattern(pattern)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", path, err))
+		} else if !re.MatchString(str) {
+			violations = append(violations, fmt.Sprintf("%s: %q does not match %s", path, str, pattern))
+		}
+	}
+	return violations
+}
+
+// pattern compiles a pattern or patternProperties regular expression once.
+func (s *schemaValidator) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %s: %w", pattern, err)
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// validateBranches applies oneOf (exactly one branch must match) or anyOf (at
+// least one must). When none matches, the violations of the closest branch,
+// the one that fails least at this level, are reported since they usually
+// point at the actual mistake.
+func (s *schemaValidator) validateBranches(branches []interface{}, value interface{}, path string, exactlyOne bool) []string {
+	matched := 0
+	var closest []string
+	closestShallow := 0
+	for _, branch := range branches {
+		violations := s.validate(branch, value, path)
+		if len(violations) == 0 {
+			matched++
+			continue
+		}
+		shallow := 0
+		for _, violation := range violations {
+			if strings.HasPrefix(violation, path+": ") {
+				shallow++
+			}
+		}
+		if closest == nil || shallow < closestShallow || (shallow == closestShallow && len(violations) < len(closest)) {
+			closest, closestShallow = violations, shallow
+		}
+	}
+	switch {
+	case matched == 0:
+		return closest
+	case exactlyOne && matched > 1:
+		return []string{fmt.Sprintf("%s: matches %d alternatives where exactly one is allowed", path, matched)}
+	}
+	return nil
+}
+
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	number, ok := schema[keyword].(float64)
+	return number, ok
+}
+
+func schemaKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaTypes(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := []string{}
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON Schema type of a decoded value; whole numbers are
+// reported as integers.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// inEnum compares decoded values structurally, as enum may list arrays and
+// objects as well as scalars.
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGeneratedCollectionMatchesTheSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest(`curl -X POST 'http://api:8080/users?page=2' -H 'Authorization: Bearer abc' -H 'Content-Type: application/json' -d '{"name":"a"}'`,
+			"spec:", "  resp:", "    status_code: 201", `    body: '{"id":1}'`),
+		"test-set-0/tests/test-2.yaml": keployTest(`curl http://api/avatars -F name=a -F avatar=@me.png`),
+		"test-set-1/tests/test-1.yaml": keployTest(`curl http://api/login -u user:secret --data-urlencode 'q=a b'`),
+	}
+	opts := testOptions()
+	opts.docs = true
+	opts.version = "1.2.3"
+	opts.output = t.TempDir() + "/output.json"
+	generateTestCollection(t, fsys, opts)
+	data, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := validateCollection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("generated collection violates the schema:\n%s", strings.Join(violations, "\n"))
+	}
+}
+
+func TestMalformedCollectionsViolateTheSchema(t *testing.T) {
+	const valid = `{
+		"info": {"name": "Atlantis", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [{"name": "users", "request": {"method": "GET", "url": {"raw": "http://api/users"}}}]
+	}`
+	if violations, err := validateCollection([]byte(valid)); err != nil || len(violations) > 0 {
+		t.Fatalf("valid collection: violations %v, err %v", violations, err)
+	}
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"no schema", `, "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"`, ``, `$.info: missing required property "schema"`},
+		{"no request", `, "request": {"method": "GET", "url": {"raw": "http://api/users"}}`, ``, `missing required property "request"`},
+		{"numeric name", `"name": "Atlantis"`, `"name": 7`, `$.info.name: expected string, got integer`},
+		{"unknown body mode", `"method": "GET"`, `"method": "GET", "body": {"mode": "binary"}`, `binary is not one of`},
+		{"header object", `"method": "GET"`, `"method": "GET", "header": {"Accept": "*/*"}`, `header`},
+		{"unknown auth", `"method": "GET"`, `"method": "GET", "auth": {"type": "magic"}`, `magic is not one of`},
+		{"numeric port", `{"raw": "http://api/users"}`, `{"raw": "http://api/users", "port": 8080}`, `.url.port: expected string, got integer`},
+		{"negative version", `"name": "Atlantis"`, `"name": "Atlantis", "version": {"major": -1, "minor": 0, "patch": 0}`, `below the minimum`},
+		{"variable without a key", `"item": [`, `"variable": [{"value": "x"}], "item": [`, `$.variable[0]: missing required property "id"`},
+		{"numeric script", `"name": "users",`, `"name": "users", "event": [{"listen": "test", "script": {"exec": 42}}],`, `.event[0].script.exec: expected array, got integer`},
+		{"event without listen", `"name": "users",`, `"name": "users", "event": [{"script": {"exec": []}}],`, `.event[0]: missing required property "listen"`},
+		{"string proxy port", `"method": "GET"`, `"method": "GET", "proxy": {"host": "proxy", "port": "3128"}`, `.proxy.port: expected integer, got string`},
+		{"numeric response code", `"name": "users",`, `"name": "users", "response": [{"code": 200.5}],`, `.code: expected integer, got number`},
+		// Parts of the format goPost does not generate itself
+		{"certificate patterns", `"method": "GET"`, `"method": "GET", "certificate": {"matches": [true]}`, `.certificate.matches[0]: expected string, got boolean`},
+		{"cookie without domain", `"name": "users",`, `"name": "users", "response": [{"cookie": [{"name": "sid", "path": "/"}]}],`, `.cookie[0]: missing required property "domain"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := strings.Replace(valid, tt.from, tt.to, 1)
+			if !json.Valid([]byte(document)) {
+				t.Fatalf("fixture is not JSON:\n%s", document)
+			}
+			violations, err := validateCollection([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(strings.Join(violations, "\n"), tt.want) {
+				t.Errorf("violations = %q, want one mentioning %s", violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedSchemaIsTheOfficialOne(t *testing.T) {
+	var schema struct {
+		ID          string                     `json:"id"`
+		Schema      string                     `json:"$schema"`
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal(collectionSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.ID != "https://schema.getpostman.com/json/collection/v2.1.0/" || schema.Schema != "http://json-schema.org/draft-04/schema#" {
+		t.Errorf("schema is %s (%s), want the draft-04 Postman v2.1.0 schema", schema.ID, schema.Schema)
+	}
+	for _, definition := range []string{"auth", "certificate", "cookie", "event", "header", "info", "item", "item-group", "proxy-config", "request", "response", "script", "url", "variable", "version"} {
+		if _, ok := schema.Definitions[definition]; !ok {
+			t.Errorf("schema has no %s definition", definition)
+		}
+	}
+}
+
+func TestDraft04Keywords(t *testing.T) {
+	const schema = `{
+		"definitions": {"tag": {"type": "string", "pattern": "^[a-z]+$", "minLength": 2}},
+		"type": "object",
+		"properties": {
+			"tags": {"type": "array", "items": {"$ref": "#/definitions/tag"}, "uniqueItems": true, "maxItems": 3},
+			"pair": {"type": "array", "items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false},
+			"ratio": {"type": "number", "minimum": 0, "exclusiveMinimum": true, "maximum": 1, "multipleOf": 0.25},
+			"kind": {"allOf": [{"type": "string"}, {"not": {"enum": ["legacy"]}}]}
+		},
+		"patternProperties": {"^x-": {"type": "string"}},
+		"additionalProperties": false,
+		"dependencies": {"ratio": ["kind"]},
+		"minProperties": 1
+	}`
+	if violations, err := validateJSON([]byte(schema), []byte(`{"tags": ["ab", "cd"], "pair": [1, "a"], "ratio": 0.5, "kind": "new", "x-note": "n"}`)); err != nil || len(violations) > 0 {
+		t.Fatalf("valid document: violations %v, err %v", violations, err)
+	}
+	tests := []struct{ document, want string }{
+		{`{}`, `$: has 0 properties, fewer than 1`},
+		{`{"other": 1}`, `$: unexpected property "other"`},
+		{`{"x-note": 1}`, `$.x-note: expected string, got integer`},
+		{`{"tags": ["ab", "ab"]}`, `$.tags: items 0 and 1 are equal`},
+		{`{"tags": ["a", "b", "c", "d"]}`, `$.tags: has 4 items, more than 3`},
+		{`{"tags": ["a"]}`, `$.tags[0]: shorter than 2 characters`},
+		{`{"tags": ["AB"]}`, `$.tags[0]: "AB" does not match ^[a-z]+$`},
+		{`{"pair": [1, "a", true]}`, `$.pair: has 3 items, at most 2 allowed`},
+		{`{"pair": ["a"]}`, `$.pair[0]: expected integer, got string`},
+		{`{"ratio": 0, "kind": "new"}`, `$.ratio: 0 is not above the minimum 0`},
+		{`{"ratio": 2, "kind": "new"}`, `$.ratio: 2 is above the maximum 1`},
+		{`{"ratio": 0.3, "kind": "new"}`, `$.ratio: 0.3 is not a multiple of 0.25`},
+		{`{"ratio": 0.5}`, `$: property "ratio" requires "kind"`},
+		{`{"kind": "legacy"}`, `$.kind: matches a schema it must not match`},
+	}
+	for _, tt := range tests {
+		violations, err := validateJSON([]byte(schema), []byte(tt.document))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(strings.Join(violations, "\n"), tt.want) {
+			t.Errorf("%s: violations = %q, want %s", tt.document, violations, tt.want)
+		}
+	}
+}
+
+func TestSchemaValidateOutputFlag(t *testing.T) {
+	dir := t.TempDir()
+	test := keployTest(`curl -X POST http://api/users -H 'Content-Type: application/json' -d '{"name":"a"}'`)
+	if err := os.MkdirAll(filepath.Join(dir, "keploy", "test-set-0", "tests"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "keploy", "test-set-0", "tests", "test-1.yaml"), test.Data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, code := runMain(t, dir, "-schema-validate-output"); code != 0 || !strings.Contains(out, "Data written to") {
+		t.Errorf("exit code %d, want a valid collection written:\n%s", code, out)
+	}
+}
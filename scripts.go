@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// addScript appends lines to the item's script for the given event
+// ("test" or "prerequest"), creating the event on first use.
+func addScript(item map[string]interface{}, listen string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	events, _ := item["event"].([]map[string]interface{})
+	for _, event := range events {
+		if event["listen"] == listen {
+			script := event["script"].(map[string]interface{})
+			script["exec"] = append(script["exec"].([]string), lines...)
+			return
+		}
+	}
+	item["event"] = append(events, map[string]interface{}{
+		"listen": listen,
+		"script": map[string]interface{}{
+			"type": "text/javascript",
+			"exec": lines,
+		},
+	})
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// statusAssertions lists the extra checks generated for a recorded status.
+type statusAssertions struct {
+	Headers map[string]string `yaml:"headers"`
+	Schema  interface{}       `yaml:"schema"`
+}
+
+// loadStatusMapping reads a -map-status-to-test file, keyed by exact status
+// codes ("404") or status classes ("4xx").
+func loadStatusMapping(path string) (map[string]statusAssertions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mapping := map[string]statusAssertions{}
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// statusTests generates Postman assertions for a recorded status code along
+// with any extra checks mapped to it, leaving out headers that are noise.
+func statusTests(status int, mapping map[string]statusAssertions, noise noiseFields) []string {
+	lines := []string{
+		fmt.Sprintf("pm.test(%s, function () {", jsString(fmt.Sprintf("Status code is %d", status))),
+		fmt.Sprintf("    pm.response.to.have.status(%d);", status),
+		"});",
+	}
+	assertions, ok := mapping[strconv.Itoa(status)]
+	if !ok {
+		assertions, ok = mapping[fmt.Sprintf("%dxx", status/100)]
+	}
+	if !ok {
+		return lines
+	}
+
+	for _, name := range sortedKeys(assertions.Headers) {
+		if noise.header(name) {
+			continue
+		}
+		lines = append(lines, headerTest(name, assertions.Headers[name])...)
+	}
+
+	if assertions.Schema != nil {
+		schema, err := json.Marshal(jsonCompatible(assertions.Schema))
+		if err == nil {
+			lines = append(lines,
+				`pm.test("Response matches schema", function () {`,
+				fmt.Sprintf("    pm.response.to.have.jsonSchema(%s);", schema),
+				"});",
+			)
+		}
+	}
+	return lines
+}
+
+// headerTest asserts a response header is present and, when value is set,
+// that it contains value.
+func headerTest(name, value string) []string {
+	if value == "" {
+		return []string{
+			fmt.Sprintf("pm.test(%s, function () {", jsString("Response has header "+name)),
+			fmt.Sprintf("    pm.response.to.have.header(%s);", jsString(name)),
+			"});",
+		}
+	}
+	return []string{
+		fmt.Sprintf("pm.test(%s, function () {", jsString(fmt.Sprintf("Header %s contains %s", name, value))),
+		fmt.Sprintf("    pm.expect(pm.response.headers.get(%s)).to.include(%s);", jsString(name), jsString(value)),
+		"});",
+	}
+}
+
+// assertionHeaderTests translates the expected response headers keploy
+// records under spec.assertions into Postman assertions, except for headers
+// listed as noise.
+func assertionHeaderTests(yamlData map[string]interface{}, noise noiseFields) []string {
+	lines := []string{}
+	equal := yamlStringMap(yamlData, "spec.assertions.header_equal")
+	for _, name := range sortedKeys(equal) {
+		if noise.header(name) {
+			continue
+		}
+		lines = append(lines,
+			fmt.Sprintf("pm.test(%s, function () {", jsString(fmt.Sprintf("Header %s is %s", name, equal[name]))),
+			fmt.Sprintf("    pm.response.to.have.header(%s, %s);", jsString(name), jsString(equal[name])),
+			"});",
+		)
+	}
+	contains := yamlStringMap(yamlData, "spec.assertions.header_contains")
+	for _, name := range sortedKeys(contains) {
+		if noise.header(name) {
+			continue
+		}
+		lines = append(lines, headerTest(name, contains[name])...)
+	}
+
+	// header_exists is either a list of names or a map of name to true
+	exists := []string{}
+	switch v := mustYamlValue(yamlData, "spec.assertions.header_exists").(type) {
+	case []interface{}:
+		for _, name := range v {
+			exists = append(exists, fmt.Sprint(name))
+		}
+	case map[interface{}]interface{}:
+		for name, enabled := range v {
+			if enabled != false {
+				exists = append(exists, fmt.Sprint(name))
+			}
+		}
+		sort.Strings(exists)
+	}
+	for _, name := range exists {
+		if noise.header(name) {
+			continue
+		}
+		lines = append(lines, headerTest(name, "")...)
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// responseTimeTests asserts the response arrives within the latency keploy
+// expected. An explicit spec.assertions.response_time (in milliseconds) is
+// used as is; otherwise, when factor is positive, the limit is the recorded
+// request-to-response time multiplied by factor.
+func responseTimeTests(yamlData map[string]interface{}, factor float64) []string {
+	limit, ok := yamlInt(yamlData, "spec.assertions.response_time")
+	if !ok && factor > 0 {
+		sent, hasSent := yamlTime(yamlData, "spec.req.timestamp")
+		received, hasReceived := yamlTime(yamlData, "spec.resp.timestamp")
+		if hasSent && hasReceived && received.After(sent) {
+			limit = int(math.Ceil(float64(received.Sub(sent).Milliseconds()) * factor))
+			ok = limit > 0
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("pm.test(%s, function () {", jsString(fmt.Sprintf("Response time is below %dms", limit))),
+		fmt.Sprintf("    pm.expect(pm.response.responseTime).to.be.below(%d);", limit),
+		"});",
+	}
+}
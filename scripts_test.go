@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatusMappingGeneratesHeaderAssertions(t *testing.T) {
+	mapping := `"201":
+  headers:
+    Location: /users/
+"4xx":
+  headers:
+    Content-Type: application/problem+json
+`
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	if err := os.WriteFile(path, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadStatusMapping(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := strings.Join(statusTests(201, loaded, nil), "\n")
+	for _, want := range []string{
+		"pm.response.to.have.status(201);",
+		`pm.expect(pm.response.headers.get("Location")).to.include("/users/");`,
+	} {
+		if !strings.Contains(created, want) {
+			t.Errorf("201 script lacks %s:\n%s", want, created)
+		}
+	}
+	notFound := strings.Join(statusTests(404, loaded, nil), "\n")
+	if !strings.Contains(notFound, `pm.expect(pm.response.headers.get("Content-Type")).to.include("application/problem+json");`) {
+		t.Errorf("404 script lacks the 4xx header assertion:\n%s", notFound)
+	}
+	ok := strings.Join(statusTests(200, loaded, nil), "\n")
+	if strings.Contains(ok, "headers.get") {
+		t.Errorf("unmapped 200 got header assertions:\n%s", ok)
+	}
+}
+
+func TestResponseTimeAssertion(t *testing.T) {
+	timestamps := []string{"spec:", "  req:", "    timestamp: 2024-05-01T10:00:00.000Z", "  resp:", "    timestamp: 2024-05-01T10:00:00.120Z"}
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users", timestamps...),
+		"test-set-0/tests/test-2.yaml": keployTest("curl http://api/orders", append(timestamps, "  assertions:", "    response_time: 500")...),
+	}
+	scripts := func(factor float64) []string {
+		opts := testOptions()
+		opts.latencyFactor = factor
+		got := []string{}
+		forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+			got = append(got, testScript(item, "test"))
+		})
+		return got
+	}
+	const assertion = "pm.expect(pm.response.responseTime).to.be.below(%d);"
+	scaled := scripts(2)
+	if want := fmt.Sprintf(assertion, 240); !strings.Contains(scaled[0], want) {
+		t.Errorf("recorded latency script lacks %s:\n%s", want, scaled[0])
+	}
+	if want := fmt.Sprintf(assertion, 500); !strings.Contains(scaled[1], want) {
+		t.Errorf("explicit response_time script lacks %s:\n%s", want, scaled[1])
+	}
+	unscaled := scripts(0)
+	if strings.Contains(unscaled[0], "responseTime") {
+		t.Errorf("factor 0 still asserts the recorded latency:\n%s", unscaled[0])
+	}
+	if want := fmt.Sprintf(assertion, 500); !strings.Contains(unscaled[1], want) {
+		t.Errorf("factor 0 dropped the explicit response_time:\n%s", unscaled[1])
+	}
+}
+
+func TestNoiseFieldsAreNotAsserted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/config.yaml": &fstest.MapFile{Data: []byte("noise:\n  header:\n    Date: []\n")},
+		"test-set-0/tests/test-1.yaml": keployTest("curl http://api/users",
+			"spec:",
+			"  assertions:",
+			"    noise:",
+			"      - header.X-Request-Id",
+			"    header_equal:",
+			"      Date: Mon, 01 Jan 2024 00:00:00 GMT",
+			"      X-Request-Id: abc",
+			"      Content-Type: application/json",
+			"    header_exists:",
+			"      - date",
+		),
+		"test-set-1/tests/test-1.yaml": keployTest("curl http://api/users",
+			"spec:",
+			"  assertions:",
+			"    header_equal:",
+			"      Date: Mon, 01 Jan 2024 00:00:00 GMT",
+		),
+	}
+	scripts := []string{}
+	forEachRequest(generateTestCollection(t, fsys, testOptions()).Items, func(item map[string]interface{}) {
+		scripts = append(scripts, testScript(item, "test"))
+	})
+	if want := `pm.response.to.have.header("Content-Type", "application/json");`; !strings.Contains(scripts[0], want) {
+		t.Errorf("script lacks %s:\n%s", want, scripts[0])
+	}
+	for _, noisy := range []string{"Date", "date", "X-Request-Id"} {
+		if strings.Contains(scripts[0], `"`+noisy+`"`) {
+			t.Errorf("script asserts the noisy %s header:\n%s", noisy, scripts[0])
+		}
+	}
+	if !strings.Contains(scripts[1], `pm.response.to.have.header("Date",`) {
+		t.Errorf("another test-set's Date header is treated as noise:\n%s", scripts[1])
+	}
+}
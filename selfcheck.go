@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// roundTripLosses renders a generated request as a curl command, parses it
+// back and reports which parts of the request did not survive the trip, so
+// lossy conversions show up as warnings rather than silently.
+func roundTripLosses(item map[string]interface{}) []string {
+	original, err := decodedItem(item)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	curl := requestToCurl(original)
+	reparsed, err := parseCurlCommand(curl, parseOptions{preserveRawUrl: true})
+	if err != nil {
+		return []string{fmt.Sprintf("the generated curl command does not parse: %v", err)}
+	}
+	if reparsed, err = decodedItem(reparsed); err != nil {
+		return []string{err.Error()}
+	}
+
+	before, _ := original["request"].(map[string]interface{})
+	after, _ := reparsed["request"].(map[string]interface{})
+	losses := []string{}
+	if a, b := fmt.Sprint(before["method"]), fmt.Sprint(after["method"]); !strings.EqualFold(a, b) {
+		losses = append(losses, fmt.Sprintf("method %s became %s", a, b))
+	}
+	if a, b := requestRawUrl(before), requestRawUrl(after); a != b {
+		losses = append(losses, fmt.Sprintf("URL %q became %q", a, b))
+	}
+	if a, b := strings.Join(headerLines(before), "\n"), strings.Join(headerLines(after), "\n"); a != b {
+		losses = append(losses, "headers changed")
+	}
+	if a, b := authSignature(before), authSignature(after); a != b {
+		losses = append(losses, "auth changed")
+	}
+	beforeBody, _ := before["body"].(map[string]interface{})
+	afterBody, _ := after["body"].(map[string]interface{})
+	if bodySignature(beforeBody) != bodySignature(afterBody) {
+		losses = append(losses, "body changed")
+	}
+	return append(losses, curlFormLosses(curl, beforeBody)...)
+}
+
+// curlFormLosses checks the --form fields of a generated curl command the way
+// curl itself reads them, which goPost's own parser does not: curl uploads a
+// value starting with @ and reads one starting with < from a file, even when
+// it is inline XML. Each text field curl would not send as written is
+// reported.
+func curlFormLosses(curl string, body map[string]interface{}) []string {
+	if body["mode"] != "formdata" {
+		return nil
+	}
+	fields := []map[string]interface{}{}
+	entries, _ := body["formdata"].([]interface{})
+	for _, v := range entries {
+		field, _ := v.(map[string]interface{})
+		if disabled, _ := field["disabled"].(bool); !disabled {
+			fields = append(fields, field)
+		}
+	}
+	losses := []string{}
+	for i, form := range parseCurlArgs(curlWords(curl)).forms {
+		if i >= len(fields) || form.literal || fields[i]["type"] == "file" {
+			continue
+		}
+		key, value, _ := strings.Cut(form.value, "=")
+		if strings.HasPrefix(value, "@") || strings.HasPrefix(value, "<") {
+			losses = append(losses, fmt.Sprintf("curl would read form field %q from a file", key))
+		}
+	}
+	return losses
+}
+
+// decodedItem round-trips an item through JSON so generated items and ones
+// read back from a collection have the same Go types.
+func decodedItem(item map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func authSignature(request map[string]interface{}) string {
+	auth, ok := request["auth"]
+	if !ok {
+		return ""
+	}
+	encoded, _ := json.Marshal(auth)
+	return string(encoded)
+}
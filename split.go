@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// manifestEntry describes one collection written by -split.
+type manifestEntry struct {
+	File     string `json:"file"`
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+}
+
+// manifestFileName is the index -split writes next to the collections.
+const manifestFileName = "manifest.json"
+
+// writeSplit writes every top-level folder of the collection (a test-set, or
+// a host with -group-by host) as a collection of its own in dir, followed by
+// a manifest listing each file with its collection name and request count.
+// Requests outside any folder share one collection named after the original.
+// Each split collection's _postman_id is derived from the original's, so a
+// fixed -collection-id keeps them stable across runs too.
+func writeSplit(dir string, collection PostmanCollection) ([]manifestEntry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	type part struct {
+		name, description string
+		items             []interface{}
+	}
+	parts := []*part{}
+	var loose *part
+	for _, v := range collection.Items {
+		item, _ := v.(map[string]interface{})
+		if children, ok := item["item"].([]interface{}); ok {
+			name, _ := item["name"].(string)
+			description, _ := item["description"].(string)
+			parts = append(parts, &part{name: name, description: description, items: children})
+			continue
+		}
+		if loose == nil {
+			loose = &part{}
+			parts = append(parts, loose)
+		}
+		loose.items = append(loose.items, v)
+	}
+
+	manifest := []manifestEntry{}
+	used := map[string]bool{manifestFileName: true}
+	for _, p := range parts {
+		sub := collection
+		sub.Items = p.items
+		sub.Info.Name = collection.Info.Name
+		if p.name != "" {
+			sub.Info.Name += " - " + p.name
+		}
+		sub.Info.PostmanID = newUUID5(uuidURLNamespace, collection.Info.PostmanID+"/"+p.name)
+		if p.description != "" {
+			sub.Info.Description = p.description
+		}
+
+		base := bodyFileName(p.name)
+		if p.name == "" {
+			base = bodyFileName(collection.Info.Name)
+		}
+		file := base + ".json"
+		for i := 2; used[file]; i++ {
+			file = fmt.Sprintf("%s-%d.json", base, i)
+		}
+		used[file] = true
+
+		data, err := json.MarshalIndent(sub, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+			return nil, err
+		}
+		requests := 0
+		forEachRequest(p.items, func(map[string]interface{}) { requests++ })
+		manifest = append(manifest, manifestEntry{File: file, Name: sub.Info.Name, Requests: requests})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"collections": manifest}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return manifest, os.WriteFile(filepath.Join(dir, manifestFileName), data, 0644)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestManifestAfterSplit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml":       keployTest("curl http://api/users"),
+		"test-set-0/tests/test-2.yaml":       keployTest("curl -X POST http://api/users -d '{}'"),
+		"test-set-0/tests/admin/test-3.yaml": keployTest("curl http://api/admin"),
+		"test-set-1/tests/test-1.yaml":       keployTest("curl http://api/orders"),
+	}
+	opts := testOptions()
+	opts.split = filepath.Join(t.TempDir(), "split")
+	collection := generateTestCollection(t, fsys, opts)
+
+	data, err := os.ReadFile(filepath.Join(opts.split, manifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest struct {
+		Collections []manifestEntry `json:"collections"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	want := []manifestEntry{
+		{File: "test-set-0.json", Name: "Atlantis - test-set-0", Requests: 3},
+		{File: "test-set-1.json", Name: "Atlantis - test-set-1", Requests: 1},
+	}
+	if !reflect.DeepEqual(manifest.Collections, want) {
+		t.Fatalf("manifest = %+v, want %+v", manifest.Collections, want)
+	}
+
+	ids := map[string]bool{collection.Info.PostmanID: true}
+	for _, entry := range want {
+		data, err := os.ReadFile(filepath.Join(opts.split, entry.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var part PostmanCollection
+		if err := json.Unmarshal(data, &part); err != nil {
+			t.Fatal(err)
+		}
+		requests := 0
+		forEachRequest(part.Items, func(map[string]interface{}) { requests++ })
+		if part.Info.Name != entry.Name || requests != entry.Requests {
+			t.Errorf("%s: %s with %d requests, want the manifest's %s with %d", entry.File, part.Info.Name, requests, entry.Name, entry.Requests)
+		}
+		if ids[part.Info.PostmanID] {
+			t.Errorf("%s: _postman_id %s is not unique", entry.File, part.Info.PostmanID)
+		}
+		ids[part.Info.PostmanID] = true
+	}
+}
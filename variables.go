@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// fillPathVariables adds a url.variable entry for every :name segment in a
+// request path. The example value comes from a concrete recording of the same
+// endpoint, preferring one made with the same method.
+func fillPathVariables(items []interface{}) {
+	type concreteRequest struct {
+		method   string
+		segments []string
+	}
+	concrete := []concreteRequest{}
+	forEachRequest(items, func(item map[string]interface{}) {
+		method, segments := requestPath(item)
+		if segments != nil && !hasPathVariable(segments) {
+			concrete = append(concrete, concreteRequest{method, segments})
+		}
+	})
+
+	forEachRequest(items, func(item map[string]interface{}) {
+		method, segments := requestPath(item)
+		if !hasPathVariable(segments) {
+			return
+		}
+		var example []string
+		for _, candidate := range concrete {
+			if !matchesTemplate(segments, candidate.segments) {
+				continue
+			}
+			if example == nil || candidate.method == method {
+				example = candidate.segments
+			}
+			if candidate.method == method {
+				break
+			}
+		}
+
+		variables := []map[string]string{}
+		for i, segment := range segments {
+			if !strings.HasPrefix(segment, ":") {
+				continue
+			}
+			value := ""
+			if example != nil {
+				value = example[i]
+			}
+			variables = append(variables, map[string]string{
+				"key":   strings.TrimPrefix(segment, ":"),
+				"value": value,
+			})
+		}
+		request := item["request"].(map[string]interface{})
+		if urlBlock, ok := request["url"].(map[string]interface{}); ok {
+			urlBlock["variable"] = variables
+		}
+	})
+}
+
+// seedPathVariables turns every path variable into a reference to a
+// collection variable of the same name and returns those variables, each
+// seeded with the first example value recorded for it, so the collection (and
+// its -bundle environment) is usable straight after import.
+func seedPathVariables(items []interface{}) []map[string]string {
+	seeded := []map[string]string{}
+	index := map[string]int{}
+	forEachRequest(items, func(item map[string]interface{}) {
+		request := item["request"].(map[string]interface{})
+		urlBlock, _ := request["url"].(map[string]interface{})
+		variables, _ := urlBlock["variable"].([]map[string]string)
+		for _, variable := range variables {
+			key := variable["key"]
+			i, ok := index[key]
+			if !ok {
+				i = len(seeded)
+				index[key] = i
+				seeded = append(seeded, map[string]string{"key": key, "value": ""})
+			}
+			if seeded[i]["value"] == "" {
+				seeded[i]["value"] = variable["value"]
+			}
+			variable["value"] = "{{" + key + "}}"
+		}
+	})
+	return seeded
+}
+
+// requestPath returns a request's method and its path split into segments,
+// or nil segments when the URL cannot be parsed.
+func requestPath(item map[string]interface{}) (string, []string) {
+	request, ok := item["request"].(map[string]interface{})
+	if !ok {
+		return "", nil
+	}
+	method, _ := request["method"].(string)
+	parsedUrl, err := requestURL(request)
+	if err != nil {
+		return method, nil
+	}
+	return strings.ToUpper(method), strings.Split(strings.Trim(postmanVariableEscapes.Replace(recordedPath(parsedUrl)), "/"), "/")
+}
+
+func hasPathVariable(segments []string) bool {
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTemplate reports whether a concrete path fits a templated one, with
+// every literal segment equal and :name segments matching anything.
+func matchesTemplate(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, segment := range template {
+		if !strings.HasPrefix(segment, ":") && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// timestampVariable is the Postman variable -timestamp-script keeps current.
+const timestampVariable = "{{timestamp}}"
+
+// addTimestampScript gives a request that references {{timestamp}} in its
+// URL, headers or body a pre-request script setting it to the current Unix
+// time in seconds, as signed requests usually expect. Other requests are left
+// alone.
+func addTimestampScript(item map[string]interface{}) {
+	encoded, err := json.Marshal(item["request"])
+	if err != nil || !strings.Contains(string(encoded), timestampVariable) {
+		return
+	}
+	addScript(item, "prerequest", []string{
+		`pm.variables.set("timestamp", Math.floor(Date.now() / 1000).toString());`,
+	})
+}
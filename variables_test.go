@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFillPathVariablesTakesExampleFromConcreteRecording(t *testing.T) {
+	collection := testCollection(t,
+		`curl --url http://api/users/:id/orders/:orderId`,
+		`curl -X POST --url http://api/users/7/orders/1`,
+		`curl --url http://api/users/42/orders/9`,
+		`curl --url http://api/carts/:cartId`,
+	)
+	fillPathVariables(collection.Items)
+
+	requests := collection.Items[0].(map[string]interface{})["item"].([]interface{})
+	variables := func(i int) interface{} {
+		return testRequest(requests[i].(map[string]interface{}))["url"].(map[string]interface{})["variable"]
+	}
+	want := []map[string]string{{"key": "id", "value": "42"}, {"key": "orderId", "value": "9"}}
+	if got := variables(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("variables = %v, want %v from the GET recording", got, want)
+	}
+	want = []map[string]string{{"key": "cartId", "value": ""}}
+	if got := variables(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("variables without a concrete recording = %v, want %v", got, want)
+	}
+	if got := variables(2); got != nil {
+		t.Errorf("concrete request got variables %v", got)
+	}
+}
+
+func TestSeedPathVariables(t *testing.T) {
+	collection := testCollection(t,
+		`curl --url http://api/users/:id`,
+		`curl --url http://api/users/42`,
+		`curl --url http://api/users/:id/orders/:orderId`,
+		`curl --url http://api/carts/:cartId`,
+	)
+	fillPathVariables(collection.Items)
+	seeded := seedPathVariables(collection.Items)
+	want := []map[string]string{{"key": "id", "value": "42"}, {"key": "orderId", "value": ""}, {"key": "cartId", "value": ""}}
+	if !reflect.DeepEqual(seeded, want) {
+		t.Errorf("seeded = %v, want %v", seeded, want)
+	}
+	requests := collection.Items[0].(map[string]interface{})["item"].([]interface{})
+	urlVariables := testRequest(requests[2].(map[string]interface{}))["url"].(map[string]interface{})["variable"]
+	if want := []map[string]string{{"key": "id", "value": "{{id}}"}, {"key": "orderId", "value": "{{orderId}}"}}; !reflect.DeepEqual(urlVariables, want) {
+		t.Errorf("path variables = %v, want references to %v", urlVariables, want)
+	}
+}
+
+func TestTimestampScriptOnlyForReferencingRequests(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test-set-0/tests/test-1.yaml": keployTest(`curl http://api/signed -H 'X-Timestamp: {{timestamp}}'`),
+		"test-set-0/tests/test-2.yaml": keployTest(`curl http://api/users`),
+		"test-set-0/tests/test-3.yaml": keployTest(`curl http://api/events -d '{"at":"{{timestamp}}"}'`),
+	}
+	opts := testOptions()
+	opts.timestampScript = true
+	scripts := []string{}
+	forEachRequest(generateTestCollection(t, fsys, opts).Items, func(item map[string]interface{}) {
+		scripts = append(scripts, testScript(item, "prerequest"))
+	})
+	const set = `pm.variables.set("timestamp", Math.floor(Date.now() / 1000).toString());`
+	for i, want := range []bool{true, false, true} {
+		if got := strings.Contains(scripts[i], set); got != want {
+			t.Errorf("request %d has the timestamp script = %v, want %v:\n%s", i+1, got, want, scripts[i])
+		}
+	}
+}
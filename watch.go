@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// watchDebounce is how long the keploy directory must stay unchanged before
+// -watch regenerates, so a burst of writes from a recording triggers one run.
+const watchDebounce = 500 * time.Millisecond
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshotDir records the size and modification time of every YAML file under
+// dir. Files and directories removed while it walks are left out rather than
+// failing the snapshot, since a recording may be rewriting them.
+func snapshotDir(dir string) (map[string]fileState, error) {
+	snapshot := map[string]fileState{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path != dir {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		snapshot[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return snapshot, err
+}
+
+func snapshotsEqual(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(state.modTime) || other.size != state.size {
+			return false
+		}
+	}
+	return true
+}
+
+// watchDir polls dir every interval and calls onChange once the YAML files
+// under it have changed and then stayed untouched for the debounce period.
+// It returns when stop is closed; a nil stop channel watches forever.
+func watchDir(dir string, interval, debounce time.Duration, onChange func(), stop <-chan struct{}) error {
+	last, err := snapshotDir(dir)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pending := false
+	var lastChange time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			current, err := snapshotDir(dir)
+			if err != nil {
+				return err
+			}
+			if !snapshotsEqual(last, current) {
+				last = current
+				pending = true
+				lastChange = now
+				continue
+			}
+			if pending && now.Sub(lastChange) >= debounce {
+				pending = false
+				onChange()
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDirRegeneratesOnceAfterAChange(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "test-set-0", "tests", "test-1.yaml")
+	if err := os.MkdirAll(filepath.Dir(testFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(testFile, []byte("curl: curl http://api/users\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watchDir(dir, 5*time.Millisecond, 50*time.Millisecond, func() { changes <- struct{}{} }, stop)
+	}()
+
+	// Let the watcher take its first snapshot, then write twice in quick
+	// succession; the debounce folds both writes into one regeneration
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(testFile, []byte("curl: curl http://api/users/1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(testFile), "test-2.yaml"), []byte("curl: curl http://api/orders\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no regeneration after the test files changed")
+	}
+	time.Sleep(150 * time.Millisecond)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("watchDir: %v", err)
+	}
+	if extra := len(changes); extra != 0 {
+		t.Errorf("regenerated %d more times, want once", extra)
+	}
+}
+
+func TestSnapshotDirIgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.yaml": "x", "notes.txt": "y"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot, err := snapshotDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot[filepath.Join(dir, "a.yaml")]; !ok || len(snapshot) != 1 {
+		t.Errorf("snapshot = %v, want only a.yaml", snapshot)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// yamlValue walks a dotted path such as "spec.resp.status_code" through a
+// decoded keploy test case.
+func yamlValue(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			current = m[key]
+		case map[interface{}]interface{}:
+			current = m[key]
+		default:
+			return nil, false
+		}
+		if current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// yamlString returns the value at path when it is a scalar, formatted as a string.
+func yamlString(data map[string]interface{}, path string) string {
+	switch v := mustYamlValue(data, path).(type) {
+	case string:
+		return v
+	case int, int64, float64, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// yamlInt returns the value at path as an integer, accepting quoted numbers.
+func yamlInt(data map[string]interface{}, path string) (int, bool) {
+	switch v := mustYamlValue(data, path).(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func mustYamlValue(data map[string]interface{}, path string) interface{} {
+	v, _ := yamlValue(data, path)
+	return v
+}
+
+// yamlStringMap returns the mapping at path with its keys and scalar values
+// as strings, e.g. a recorded header block.
+func yamlStringMap(data map[string]interface{}, path string) map[string]string {
+	result := map[string]string{}
+	switch m := mustYamlValue(data, path).(type) {
+	case map[interface{}]interface{}:
+		for key, value := range m {
+			result[fmt.Sprint(key)] = fmt.Sprint(value)
+		}
+	case map[string]interface{}:
+		for key, value := range m {
+			result[key] = fmt.Sprint(value)
+		}
+	}
+	return result
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values produced by
+// yaml.v2 into map[string]interface{} so they can be marshaled as JSON.
+func jsonCompatible(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, inner := range value {
+			m[fmt.Sprint(key)] = jsonCompatible(inner)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, inner := range value {
+			m[key] = jsonCompatible(inner)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, inner := range value {
+			list[i] = jsonCompatible(inner)
+		}
+		return list
+	}
+	return v
+}
+
+// recordedHost returns the host a test case was recorded against, taken from
+// its metadata, its Host header or the structured request URL.
+func recordedHost(yamlData map[string]interface{}) string {
+	if host := yamlString(yamlData, "spec.metadata.host"); host != "" {
+		return host
+	}
+	for name, value := range yamlStringMap(yamlData, "spec.req.header") {
+		if strings.EqualFold(name, "Host") && value != "" {
+			return value
+		}
+	}
+	if parsedUrl, err := url.Parse(yamlString(yamlData, "spec.req.url")); err == nil && parsedUrl.Host != "" {
+		return parsedUrl.Scheme + "://" + parsedUrl.Host
+	}
+	return ""
+}
+
+// testSummary returns the human readable summary of a test case, which may be
+// recorded at the top level or under spec.
+func testSummary(yamlData map[string]interface{}) string {
+	if summary := strings.TrimSpace(yamlString(yamlData, "summary")); summary != "" {
+		return summary
+	}
+	return strings.TrimSpace(yamlString(yamlData, "spec.summary"))
+}
+
+// curlField returns the recorded curl command, which is usually one string but
+// may also be stored as a list of lines.
+func curlField(value interface{}) (string, bool) {
+	switch curl := value.(type) {
+	case string:
+		return curl, true
+	case []interface{}:
+		lines := make([]string, 0, len(curl))
+		for _, line := range curl {
+			text, ok := line.(string)
+			if !ok {
+				return "", false
+			}
+			lines = append(lines, text)
+		}
+		return strings.Join(lines, "\n"), len(lines) > 0
+	}
+	return "", false
+}
+
+// defaultCurlFields are the places keploy and hand-written tests keep the
+// curl command, tried in order.
+const defaultCurlFields = "curl,spec.curl,request.curl"
+
+// findCurl returns the curl command stored at the first of paths that holds
+// one.
+func findCurl(data map[string]interface{}, paths []string) (string, bool) {
+	for _, path := range paths {
+		if curl, ok := curlField(mustYamlValue(data, path)); ok {
+			return curl, true
+		}
+	}
+	return "", false
+}
+
+// yamlTime returns the timestamp at path, which yaml.v2 may decode either as
+// a time.Time or as the raw RFC 3339 string.
+func yamlTime(data map[string]interface{}, path string) (time.Time, bool) {
+	switch v := mustYamlValue(data, path).(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		return t, err == nil
+	}
+	return time.Time{}, false
+}